@@ -30,6 +30,7 @@ func gracefulShutdown(apiServer *http.Server, done chan bool) {
 	if err := apiServer.Shutdown(ctx); err != nil {
 		log.Printf("Server forced to shutdown with error: %v", err)
 	}
+	server.RunShutdownHooks(ctx)
 
 	log.Println("Server exiting")
 
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/joho/godotenv/autoload"
+)
+
+// GetEnv returns the value of an environment variable or the fallback if unset
+func GetEnv(key, fallback string) string {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// GetEnvInt returns an integer environment variable or the fallback if unset or invalid
+func GetEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(GetEnv(key, ""))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// GetEnvFloat returns a float environment variable or the fallback if unset or invalid
+func GetEnvFloat(key string, fallback float64) float64 {
+	value, err := strconv.ParseFloat(GetEnv(key, ""), 64)
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// GetEnvBool returns a boolean environment variable or the fallback if unset or invalid
+func GetEnvBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(GetEnv(key, ""))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// GetEnvDuration returns a duration environment variable, accepting either a Go
+// duration string ("90s", "5m") or a plain number of seconds
+func GetEnvDuration(key string, fallback time.Duration) time.Duration {
+	raw := GetEnv(key, "")
+	if raw == "" {
+		return fallback
+	}
+	if seconds, err := strconv.Atoi(raw); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if duration, err := time.ParseDuration(raw); err == nil {
+		return duration
+	}
+	return fallback
+}
+
+// GetEnvList returns a comma-separated environment variable as a slice, skipping empty entries
+func GetEnvList(key string) []string {
+	var values []string
+	for _, part := range strings.Split(GetEnv(key, ""), ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+// GetEnvMap returns a comma-separated list of key=value pairs (e.g. "python=10,go=20")
+// as a map, skipping malformed entries
+func GetEnvMap(key string) map[string]string {
+	values := make(map[string]string)
+	for _, pair := range GetEnvList(key) {
+		k, v, found := strings.Cut(pair, "=")
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if !found || k == "" {
+			continue
+		}
+		values[k] = v
+	}
+	return values
+}
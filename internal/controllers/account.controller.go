@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"ignis/internal/middleware"
+	"ignis/internal/models"
+	"ignis/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccountController handles HTTP requests for users' tiers and limits
+type AccountController struct {
+	policyService *services.PolicyService
+	rateLimiter   *services.RateLimiterService
+}
+
+// NewAccountController creates a new instance of AccountController
+func NewAccountController(policyService *services.PolicyService, rateLimiter *services.RateLimiterService) *AccountController {
+	return &AccountController{
+		policyService: policyService,
+		rateLimiter:   rateLimiter,
+	}
+}
+
+// GetAccount handles GET /public/account - the API key owner's tier, its limits and current usage
+func (c *AccountController) GetAccount(ctx *gin.Context) {
+	apiKey, exists := middleware.GetAPIKeyFromContext(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "API key authentication required"})
+		return
+	}
+
+	account, err := c.policyService.GetAccount(apiKey.ClerkUserID)
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+
+	respondJSON(ctx, http.StatusOK, gin.H{"data": account})
+}
+
+// GetLimits handles GET /public/account/limits - the effective limits for the caller and their
+// current consumption. API keys report their own rate limit and job submission quota; users
+// signed in with Clerk report the per-route user rate limit.
+func (c *AccountController) GetLimits(ctx *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	limits, err := c.policyService.GetLimits(userID)
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+
+	window := time.Minute
+	limits.RateLimitWindow = window.String()
+
+	if apiKey, ok := middleware.GetAPIKeyFromContext(ctx); ok {
+		limits.Unlimited = apiKey.Unlimited
+		if !apiKey.Unlimited {
+			limits.RateLimit = apiKey.RateLimit
+			if c.rateLimiter != nil {
+				used, _, err := c.rateLimiter.Peek(services.GetAPIKeyJobQuotaKey(strconv.Itoa(int(apiKey.ID))), apiKey.RateLimit, window)
+				if err != nil {
+					respondInternalError(ctx, err)
+					return
+				}
+				limits.Usage.JobSubmissions = &used
+			}
+		}
+	} else {
+		// Clerk-authenticated routes use StandardUserRateLimit
+		limits.RateLimit = middleware.StandardUserRequestLimit
+	}
+
+	respondJSON(ctx, http.StatusOK, gin.H{"data": limits})
+}
+
+// SetUserTier handles PUT /admin/users/:user_id/tier
+func (c *AccountController) SetUserTier(ctx *gin.Context) {
+	clerkUserID := ctx.Param("user_id")
+	if clerkUserID == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "User ID is required"})
+		return
+	}
+
+	var req models.UserTierUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tier, err := models.ParseUserTier(req.Tier)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.policyService.SetTier(clerkUserID, tier); err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+
+	account, err := c.policyService.GetAccount(clerkUserID)
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+
+	respondJSON(ctx, http.StatusOK, gin.H{"data": account})
+}
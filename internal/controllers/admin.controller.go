@@ -0,0 +1,246 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ignis/internal/config"
+	"ignis/internal/middleware"
+	"ignis/internal/models"
+	"ignis/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminController handles operator-only HTTP requests
+type AdminController struct {
+	jobService    *services.JobService
+	apiKeyService *services.APIKeyService
+	rateLimiter   *services.RateLimiterService
+	canaryTimeout time.Duration
+}
+
+// NewAdminController creates a new instance of AdminController
+func NewAdminController(jobService *services.JobService, apiKeyService *services.APIKeyService, rateLimiter *services.RateLimiterService) *AdminController {
+	return &AdminController{
+		jobService:    jobService,
+		apiKeyService: apiKeyService,
+		rateLimiter:   rateLimiter,
+		canaryTimeout: config.GetEnvDuration("HEALTH_CANARY_TIMEOUT", 10*time.Second),
+	}
+}
+
+// InspectRateLimit handles GET /admin/rate-limit/inspect?type=&id=&endpoint= - shows the limiter key,
+// effective limit and current usage for an API key ("api"), a user ("user") or everyone ("global")
+// on a route such as /api/v1/public/execute, without consuming a request. Pass limit to override
+// the limit the usage is compared against.
+func (c *AdminController) InspectRateLimit(ctx *gin.Context) {
+	limitType := ctx.Query("type")
+	id := ctx.Query("id")
+	endpoint := ctx.Query("endpoint")
+	if endpoint == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "endpoint is required"})
+		return
+	}
+
+	// Limits and windows mirror what the middleware applies for each type
+	var key string
+	var limit int
+	switch limitType {
+	case "api":
+		apiKeyID, err := strconv.ParseUint(id, 10, 32)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+			return
+		}
+		limit, err = c.apiKeyService.GetRateLimit(uint(apiKeyID))
+		if err != nil {
+			respondServiceError(ctx, err)
+			return
+		}
+		key = services.GetAPIKeyRateLimitKey(id, endpoint)
+	case "user":
+		if id == "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "id is required"})
+			return
+		}
+		key = services.GetUserRateLimitKey(id, endpoint)
+		limit = middleware.StandardUserRequestLimit
+	case "global":
+		key = services.GetGlobalRateLimitKey(endpoint)
+		limit = middleware.StandardGlobalRequestLimit
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "type must be one of: api, user, global"})
+		return
+	}
+
+	if limitParam := ctx.Query("limit"); limitParam != "" {
+		override, err := strconv.Atoi(limitParam)
+		if err != nil || override < 1 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = override
+	}
+
+	window := time.Minute
+	used, remaining, err := c.rateLimiter.Peek(key, limit, window)
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"type":      limitType,
+			"id":        id,
+			"endpoint":  endpoint,
+			"key":       key,
+			"backend":   c.rateLimiter.Backend(),
+			"limit":     limit,
+			"window":    window.String(),
+			"used":      used,
+			"remaining": remaining,
+			"limited":   remaining == 0,
+		},
+	})
+}
+
+// GetJobsWithUndeliveredWebhooks handles GET /admin/jobs/undelivered-webhooks - across all users
+func (c *AdminController) GetJobsWithUndeliveredWebhooks(ctx *gin.Context) {
+	limit, offset := parsePagination(ctx)
+
+	issues, total, err := c.jobService.GetJobsWithUndeliveredWebhooks("", limit, offset)
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+
+	respondPage(ctx, issues, total, len(issues), limit, offset)
+}
+
+// GetJob handles GET /admin/jobs/:job_id - any user's job, with the NATS subject it was published to
+func (c *AdminController) GetJob(ctx *gin.Context) {
+	job, err := c.jobService.GetJobRouting(ctx.Request.Context(), ctx.Param("job_id"))
+	if err != nil {
+		if respondIfTimedOut(ctx, err) {
+			return
+		}
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+
+	respondJSON(ctx, http.StatusOK, gin.H{"data": job})
+}
+
+// ForceJobStatus handles POST /admin/jobs/:job_id/force-status - completes or fails a wedged job
+// as if its worker had reported it
+func (c *AdminController) ForceJobStatus(ctx *gin.Context) {
+	adminID, exists := middleware.GetUserIDFromContext(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.JobForceStatusRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	job, err := c.jobService.ForceJobStatus(ctx.Param("job_id"), req, adminID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrJobNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		case errors.Is(err, services.ErrJobAlreadyFinished):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			respondInternalError(ctx, err)
+		}
+		return
+	}
+
+	respondJSON(ctx, http.StatusOK, gin.H{"data": job})
+}
+
+// SetAPIKeyTier handles PUT /admin/api-keys/:id/tier - the tier caps the priority of the key's jobs
+func (c *AdminController) SetAPIKeyTier(ctx *gin.Context) {
+	idParam := ctx.Param("id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return
+	}
+
+	var req models.APIKeyTierUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	apiKey, err := c.apiKeyService.SetTier(uint(id), models.UserTier(req.Tier))
+	if err != nil {
+		respondServiceError(ctx, err)
+		return
+	}
+
+	respondJSON(ctx, http.StatusOK, gin.H{"data": apiKey})
+}
+
+// GetInFlightJobs handles GET /admin/stats/in-flight - Counts of received and running jobs
+func (c *AdminController) GetInFlightJobs(ctx *gin.Context) {
+	counts, err := c.jobService.GetInFlightCounts()
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+
+	respondJSON(ctx, http.StatusOK, gin.H{"data": counts})
+}
+
+// GetQueueDepth handles GET /admin/queue - how many jobs are waiting for a worker, for autoscalers
+func (c *AdminController) GetQueueDepth(ctx *gin.Context) {
+	depth, err := c.jobService.QueueDepth()
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+
+	respondJSON(ctx, http.StatusOK, gin.H{"data": gin.H{
+		"depth":  depth,
+		"source": "database",
+	}})
+}
+
+// DeepHealthCheck handles GET /health/deep - Runs a canary job through the whole pipeline
+func (c *AdminController) DeepHealthCheck(ctx *gin.Context) {
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), c.canaryTimeout)
+	defer cancel()
+
+	result := c.jobService.RunCanary(timeoutCtx)
+
+	status := http.StatusOK
+	if !result.Success {
+		status = http.StatusServiceUnavailable
+	}
+	ctx.JSON(status, gin.H{"data": result})
+}
+
+// parsePagination reads limit (1-100, default 50) and offset (default 0) query parameters
+func parsePagination(ctx *gin.Context) (int, int) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 50
+	}
+
+	offset, err := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	return limit, offset
+}
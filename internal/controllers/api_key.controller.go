@@ -38,13 +38,21 @@ func (c *APIKeyController) CreateAPIKey(ctx *gin.Context) {
 		return
 	}
 
+	if req.Unlimited && !middleware.IsAdmin(ctx) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Only admins can create unlimited API keys"})
+		return
+	}
+
 	apiKey, err := c.apiKeyService.CreateAPIKey(req, userID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if respondIfPolicyLimit(ctx, err) {
+			return
+		}
+		respondServiceError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusCreated, gin.H{"data": apiKey})
+	respondJSON(ctx, http.StatusCreated, gin.H{"data": apiKey})
 }
 
 // GetAPIKeys handles GET /api-keys
@@ -56,13 +64,46 @@ func (c *APIKeyController) GetAPIKeys(ctx *gin.Context) {
 		return
 	}
 
-	apiKeys, err := c.apiKeyService.GetAPIKeysByUser(userID)
+	limit, offset := parsePagination(ctx)
+	opts := models.APIKeyListOptions{
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	if isActiveParam := ctx.Query("is_active"); isActiveParam != "" {
+		isActive, err := strconv.ParseBool(isActiveParam)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid is_active value"})
+			return
+		}
+		opts.IsActive = &isActive
+	}
+
+	if expiredParam := ctx.Query("expired"); expiredParam != "" {
+		expired, err := strconv.ParseBool(expiredParam)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expired value"})
+			return
+		}
+		opts.Expired = &expired
+	}
+
+	// metadata[env]=prod&metadata[team]=infra matches keys carrying both entries
+	if metadata := ctx.QueryMap("metadata"); len(metadata) > 0 {
+		if len(metadata) > 20 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "At most 20 metadata filters are allowed"})
+			return
+		}
+		opts.Metadata = metadata
+	}
+
+	apiKeys, total, err := c.apiKeyService.GetAPIKeysByUser(userID, opts)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondInternalError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"data": apiKeys})
+	respondPage(ctx, apiKeys, total, len(apiKeys), limit, offset)
 }
 
 // GetAPIKey handles GET /api-keys/:id
@@ -87,7 +128,7 @@ func (c *APIKeyController) GetAPIKey(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"data": apiKey})
+	respondJSON(ctx, http.StatusOK, gin.H{"data": apiKey})
 }
 
 // UpdateAPIKey handles PUT/PATCH /api-keys/:id
@@ -106,28 +147,50 @@ func (c *APIKeyController) UpdateAPIKey(ctx *gin.Context) {
 		return
 	}
 
-	var req struct {
-		IsActive bool `json:"is_active"`
-	}
+	var req models.APIKeyUpdateRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	err = c.apiKeyService.UpdateAPIKey(uint(id), userID, req.IsActive)
+	err = c.apiKeyService.UpdateAPIKey(uint(id), userID, req)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondServiceError(ctx, err)
 		return
 	}
 
 	// Get updated API key
 	apiKey, err := c.apiKeyService.GetAPIKeyByID(uint(id), userID)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve updated API key"})
+		respondInternalError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"data": apiKey})
+	respondJSON(ctx, http.StatusOK, gin.H{"data": apiKey})
+}
+
+// BulkUpdateAPIKeys handles PATCH /api-keys/bulk
+func (c *APIKeyController) BulkUpdateAPIKeys(ctx *gin.Context) {
+	// Get user ID from context (Clerk authentication required)
+	userID, exists := middleware.GetUserIDFromContext(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.APIKeyBulkUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := c.apiKeyService.BulkSetActive(req.IDs, userID, *req.IsActive)
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+
+	respondJSON(ctx, http.StatusOK, gin.H{"data": result})
 }
 
 // DeleteAPIKey handles DELETE /api-keys/:id
@@ -148,7 +211,7 @@ func (c *APIKeyController) DeleteAPIKey(ctx *gin.Context) {
 
 	err = c.apiKeyService.DeleteAPIKey(uint(id), userID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondServiceError(ctx, err)
 		return
 	}
 
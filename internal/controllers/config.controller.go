@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"net/http"
+
+	"ignis/internal/middleware"
+	"ignis/internal/models"
+	"ignis/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConfigController handles exporting and importing a user's webhooks and API keys
+type ConfigController struct {
+	configService *services.ConfigService
+}
+
+// NewConfigController creates a new instance of ConfigController
+func NewConfigController(configService *services.ConfigService) *ConfigController {
+	return &ConfigController{
+		configService: configService,
+	}
+}
+
+// ExportConfig handles GET /public/config/export
+func (c *ConfigController) ExportConfig(ctx *gin.Context) {
+	// Get user ID from context (Clerk authentication required)
+	userID, exists := middleware.GetUserIDFromContext(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	export, err := c.configService.ExportConfig(userID)
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+
+	ctx.Header("Content-Disposition", `attachment; filename="ignis-config.json"`)
+	respondJSON(ctx, http.StatusOK, gin.H{"data": export})
+}
+
+// ImportConfig handles POST /public/config/import - the body is the data of an export
+func (c *ConfigController) ImportConfig(ctx *gin.Context) {
+	// Get user ID from context (Clerk authentication required)
+	userID, exists := middleware.GetUserIDFromContext(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.ConfigExport
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := c.configService.ImportConfig(userID, req)
+	if err != nil {
+		respondServiceError(ctx, err)
+		return
+	}
+	for i := range result.Webhooks {
+		if skipErr := result.Webhooks[i].SkipErr; skipErr != nil {
+			result.Webhooks[i].Skipped = clientErrorMessage(ctx, skipErr)
+		}
+	}
+	for i := range result.APIKeys {
+		if skipErr := result.APIKeys[i].SkipErr; skipErr != nil {
+			result.APIKeys[i].Skipped = clientErrorMessage(ctx, skipErr)
+		}
+	}
+
+	// The response carries the new secrets and keys
+	ctx.Header("Cache-Control", "no-store")
+	respondJSON(ctx, http.StatusOK, gin.H{"data": result})
+}
@@ -1,8 +1,11 @@
 package controllers
 
 import (
+	"encoding/csv"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"ignis/internal/middleware"
 	"ignis/internal/models"
@@ -37,14 +40,50 @@ func (c *JobController) CreateJob(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if apiKey, ok := middleware.GetAPIKeyFromContext(ctx); ok {
+		req.APIKeyID = &apiKey.ID
+		req.Priority = apiKey.JobPriority(req.Priority)
+	}
 
-	job, err := c.jobService.CreateJob(req, userID)
+	job, err := c.jobService.CreateJob(ctx.Request.Context(), req, userID)
 	if err != nil {
+		if respondIfTimedOut(ctx, err) || respondIfOverCapacity(ctx, err, c.jobService.CapacityRetryAfter()) || respondIfPolicyLimit(ctx, err) || respondIfCoolingDown(ctx, err) {
+			return
+		}
+		respondServiceError(ctx, err)
+		return
+	}
+
+	respondJSON(ctx, http.StatusCreated, gin.H{"data": job})
+}
+
+// ImportJobs handles POST /jobs/import - imports finished jobs from another platform without running them
+func (c *JobController) ImportJobs(ctx *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.JobImportRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	ctx.JSON(http.StatusCreated, gin.H{"data": job})
+	jobs, err := c.jobService.ImportJobs(userID, req.Jobs)
+	if err != nil {
+		if respondIfPolicyLimit(ctx, err) {
+			return
+		}
+		respondServiceError(ctx, err)
+		return
+	}
+
+	respondJSON(ctx, http.StatusCreated, gin.H{
+		"data":     jobs,
+		"imported": len(jobs),
+	})
 }
 
 // GetJob handles GET /jobs/:id
@@ -62,7 +101,7 @@ func (c *JobController) GetJob(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"data": job})
+	respondJSON(ctx, http.StatusOK, gin.H{"data": job})
 }
 
 // GetJobByJobID handles GET /jobs/job_id/:job_id
@@ -73,24 +112,27 @@ func (c *JobController) GetJobByJobID(ctx *gin.Context) {
 		return
 	}
 
-	job, err := c.jobService.GetJobByJobID(jobID)
+	job, err := c.jobService.GetJobByJobID(ctx.Request.Context(), jobID)
 	if err != nil {
+		if respondIfTimedOut(ctx, err) {
+			return
+		}
 		ctx.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"data": job})
+	respondJSON(ctx, http.StatusOK, gin.H{"data": job})
 }
 
 // GetAllJobs handles GET /jobs
 func (c *JobController) GetAllJobs(ctx *gin.Context) {
 	jobs, err := c.jobService.GetAllJobs()
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondInternalError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"data": jobs})
+	respondJSON(ctx, http.StatusOK, gin.H{"data": jobs})
 }
 
 // GetJobsByUser handles GET /users/:id/jobs - now gets jobs for current authenticated user
@@ -104,11 +146,11 @@ func (c *JobController) GetJobsByUser(ctx *gin.Context) {
 
 	jobs, err := c.jobService.GetJobsByClerkUserID(userID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondServiceError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"data": jobs})
+	respondJSON(ctx, http.StatusOK, gin.H{"data": jobs})
 }
 
 // GetMyJobs handles GET /jobs/my - gets jobs for current authenticated user
@@ -122,11 +164,120 @@ func (c *JobController) GetMyJobs(ctx *gin.Context) {
 
 	jobs, err := c.jobService.GetJobsByClerkUserID(userID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondServiceError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"data": jobs})
+	respondJSON(ctx, http.StatusOK, gin.H{"data": jobs})
+}
+
+// GetJobsWithUndeliveredWebhooks handles GET /jobs/undelivered-webhooks - jobs whose notifications didn't arrive
+func (c *JobController) GetJobsWithUndeliveredWebhooks(ctx *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	limit, offset := parsePagination(ctx)
+
+	issues, total, err := c.jobService.GetJobsWithUndeliveredWebhooks(userID, limit, offset)
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+
+	respondPage(ctx, issues, total, len(issues), limit, offset)
+}
+
+// GetScheduledJobs handles GET /jobs/scheduled - jobs waiting for their run time
+func (c *JobController) GetScheduledJobs(ctx *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	jobs, err := c.jobService.GetScheduledJobs(userID)
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+
+	respondJSON(ctx, http.StatusOK, gin.H{"data": jobs})
+}
+
+// CancelScheduledJob handles DELETE /jobs/scheduled/:job_id - cancels a job before it runs
+func (c *JobController) CancelScheduledJob(ctx *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	job, err := c.jobService.CancelScheduledJob(ctx.Param("job_id"), userID)
+	if err != nil {
+		if errors.Is(err, services.ErrJobNotScheduled) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "Job is no longer scheduled"})
+			return
+		}
+		respondServiceError(ctx, err)
+		return
+	}
+
+	respondJSON(ctx, http.StatusOK, gin.H{"data": job})
+}
+
+// CancelJob handles POST /jobs/:job_id/cancel - cancels a job that hasn't finished yet
+func (c *JobController) CancelJob(ctx *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	// The route wildcard is named "id" to share the /jobs/:id segment, but it carries the public job ID
+	job, err := c.jobService.CancelJob(ctx.Param("id"), userID)
+	if err != nil {
+		if errors.Is(err, services.ErrJobNotCancellable) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "Job has already finished"})
+			return
+		}
+		respondServiceError(ctx, err)
+		return
+	}
+
+	respondJSON(ctx, http.StatusOK, gin.H{"data": job})
+}
+
+// RevalidateJob handles POST /jobs/:job_id/revalidate - reruns a finished job and reports whether
+// the outcome changed. The comparison is included if the rerun finishes within the request timeout.
+func (c *JobController) RevalidateJob(ctx *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	// The route wildcard is named "id" to share the /jobs/:id segment, but it carries the public job ID
+	revalidation, err := c.jobService.RevalidateJob(ctx.Request.Context(), ctx.Param("id"), userID)
+	if err != nil {
+		if errors.Is(err, services.ErrJobNotRevalidatable) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if respondIfOverCapacity(ctx, err, c.jobService.CapacityRetryAfter()) || respondIfPolicyLimit(ctx, err) || respondIfCoolingDown(ctx, err) {
+			return
+		}
+		respondServiceError(ctx, err)
+		return
+	}
+
+	status := http.StatusOK
+	if revalidation.OutcomeChanged == nil {
+		status = http.StatusAccepted
+	}
+	respondJSON(ctx, status, gin.H{"data": revalidation})
 }
 
 // GetJobsByStatus handles GET /jobs/status/:status
@@ -136,18 +287,114 @@ func (c *JobController) GetJobsByStatus(ctx *gin.Context) {
 
 	// Validate status
 	switch status {
-	case models.JobStatusReceived, models.JobStatusRunning, models.JobStatusCompleted, models.JobStatusFailed:
+	case models.JobStatusReceived, models.JobStatusRunning, models.JobStatusCompleted, models.JobStatusFailed,
+		models.JobStatusScheduled, models.JobStatusCancelled:
 		// Valid status
 	default:
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status. Valid values: received, running, completed, failed"})
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status. Valid values: received, running, completed, failed, scheduled, cancelled"})
 		return
 	}
 
 	jobs, err := c.jobService.GetJobsByStatus(status)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondInternalError(ctx, err)
+		return
+	}
+
+	respondJSON(ctx, http.StatusOK, gin.H{"data": jobs})
+}
+
+// SearchJobs handles GET /jobs/search - finds the current user's jobs by name or description
+func (c *JobController) SearchJobs(ctx *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	filter, ok := parseJobListFilter(ctx)
+	if !ok {
+		return
+	}
+	limit, offset := parsePagination(ctx)
+
+	jobs, total, err := c.jobService.SearchJobs(userID, filter, limit, offset)
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+
+	respondPage(ctx, jobs, total, len(jobs), limit, offset)
+}
+
+// ExportJobsCSV handles GET /jobs/export.csv - streams the current user's jobs as CSV
+func (c *JobController) ExportJobsCSV(ctx *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	filter, ok := parseJobListFilter(ctx)
+	if !ok {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"data": jobs})
+	ctx.Header("Content-Type", "text/csv; charset=utf-8")
+	ctx.Header("Content-Disposition", `attachment; filename="jobs.csv"`)
+	ctx.Status(http.StatusOK)
+
+	writer := csv.NewWriter(ctx.Writer)
+	_ = writer.Write([]string{"id", "language", "status", "exec_duration", "mem_usage", "created_at"})
+
+	loc := middleware.GetTimezoneFromContext(ctx)
+	rowCount := 0
+	err := c.jobService.StreamJobsByClerkUserID(userID, filter, func(job models.Job) error {
+		err := writer.Write([]string{
+			job.JobID,
+			job.Language,
+			string(job.Status),
+			strconv.Itoa(job.ExecDuration),
+			strconv.FormatInt(job.MemUsage, 10),
+			models.FormatTimestamp(job.CreatedAt, loc),
+		})
+		if err != nil {
+			return err
+		}
+
+		// Flush in batches so rows reach the client as they are read
+		rowCount++
+		if rowCount%500 == 0 {
+			writer.Flush()
+			ctx.Writer.Flush()
+		}
+		return writer.Error()
+	})
+
+	writer.Flush()
+	if err != nil {
+		// Headers are already sent, so record the error for the logger and cut the stream short
+		_ = ctx.Error(err)
+	}
+}
+
+// parseJobListFilter reads the status, language and q query parameters, responding with
+// 400 and returning false if the status is invalid
+func parseJobListFilter(ctx *gin.Context) (models.JobListFilter, bool) {
+	filter := models.JobListFilter{
+		Status:   models.JobStatus(ctx.Query("status")),
+		Language: ctx.Query("language"),
+		Search:   strings.TrimSpace(ctx.Query("q")),
+	}
+
+	switch filter.Status {
+	case "", models.JobStatusReceived, models.JobStatusRunning, models.JobStatusCompleted, models.JobStatusFailed,
+		models.JobStatusScheduled, models.JobStatusCancelled:
+		// Valid status
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status. Valid values: received, running, completed, failed, scheduled, cancelled"})
+		return filter, false
+	}
+
+	return filter, true
 }
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+
+	"ignis/internal/middleware"
+	"ignis/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JobArtifactController handles HTTP requests for files produced by jobs
+type JobArtifactController struct {
+	jobService *services.JobService
+}
+
+// NewJobArtifactController creates a new instance of JobArtifactController
+func NewJobArtifactController(jobService *services.JobService) *JobArtifactController {
+	return &JobArtifactController{
+		jobService: jobService,
+	}
+}
+
+// GetArtifacts handles GET /jobs/:job_id/artifacts
+func (c *JobArtifactController) GetArtifacts(ctx *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	// The route wildcard is named "id" to share the /jobs/:id segment, but it carries the public job ID
+	jobID := ctx.Param("id")
+	if jobID == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Job ID is required"})
+		return
+	}
+
+	artifacts, err := c.jobService.GetJobArtifacts(jobID, userID)
+	if err != nil {
+		respondServiceError(ctx, err)
+		return
+	}
+
+	respondJSON(ctx, http.StatusOK, gin.H{"data": artifacts})
+}
+
+// DownloadArtifact handles GET /jobs/:job_id/artifacts/:artifact_id - streams the artifact's content
+func (c *JobArtifactController) DownloadArtifact(ctx *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	jobID := ctx.Param("id")
+	if jobID == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Job ID is required"})
+		return
+	}
+
+	artifactID, err := strconv.ParseUint(ctx.Param("artifact_id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid artifact ID"})
+		return
+	}
+
+	artifact, data, err := c.jobService.GetJobArtifactContent(jobID, uint(artifactID), userID)
+	if err != nil {
+		respondServiceError(ctx, err)
+		return
+	}
+
+	contentType := artifact.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	// Always download rather than render: the content type comes from the uploader
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": artifact.Filename})
+	if disposition == "" {
+		disposition = "attachment"
+	}
+	ctx.Header("Content-Disposition", disposition)
+	ctx.Header("X-Content-Type-Options", "nosniff")
+	ctx.Data(http.StatusOK, contentType, data)
+}
@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"net/http"
+
+	"ignis/internal/middleware"
+	"ignis/internal/models"
+	"ignis/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JobCommentController handles HTTP requests for job comments
+type JobCommentController struct {
+	jobCommentService *services.JobCommentService
+}
+
+// NewJobCommentController creates a new instance of JobCommentController
+func NewJobCommentController(jobCommentService *services.JobCommentService) *JobCommentController {
+	return &JobCommentController{
+		jobCommentService: jobCommentService,
+	}
+}
+
+// CreateComment handles POST /jobs/:job_id/comments
+func (c *JobCommentController) CreateComment(ctx *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	// The route wildcard is named "id" to share the /jobs/:id segment, but it carries the public job ID
+	jobID := ctx.Param("id")
+	if jobID == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Job ID is required"})
+		return
+	}
+
+	var req models.JobCommentCreateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	comment, err := c.jobCommentService.CreateComment(jobID, userID, req)
+	if err != nil {
+		respondServiceError(ctx, err)
+		return
+	}
+
+	respondJSON(ctx, http.StatusCreated, gin.H{"data": comment})
+}
+
+// GetComments handles GET /jobs/:job_id/comments
+func (c *JobCommentController) GetComments(ctx *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	jobID := ctx.Param("id")
+	if jobID == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Job ID is required"})
+		return
+	}
+
+	comments, err := c.jobCommentService.GetComments(jobID, userID)
+	if err != nil {
+		respondServiceError(ctx, err)
+		return
+	}
+
+	respondJSON(ctx, http.StatusOK, gin.H{"data": comments})
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"ignis/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cachedMetadata is a serialized response body for a read-only metadata endpoint
+type cachedMetadata struct {
+	body      []byte
+	etag      string
+	registry  string // Language registry fingerprint the body was built from
+	expiresAt time.Time
+}
+
+// metadataCache keeps the serialized responses of endpoints that return essentially static data,
+// such as /public/status and /public/languages, and answers conditional requests from their ETags.
+// Entries are rebuilt when they expire or the language registry changes.
+type metadataCache struct {
+	ttl     time.Duration // 0 disables caching; responses are still sent with an ETag
+	mu      sync.Mutex
+	entries map[string]*cachedMetadata
+}
+
+// newMetadataCache creates a metadata cache whose entries live for ttl
+func newMetadataCache(ttl time.Duration) *metadataCache {
+	return &metadataCache{
+		ttl:     ttl,
+		entries: make(map[string]*cachedMetadata),
+	}
+}
+
+// serve writes the cached response for key, building it with build when missing or stale. A
+// matching If-None-Match gets 304 Not Modified. The ETag is weak since ResponseCasing may re-key
+// the same data for clients that ask for camelCase.
+func (m *metadataCache) serve(ctx *gin.Context, key string, build func() interface{}) {
+	entry, err := m.get(key, build)
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+
+	ctx.Header("ETag", entry.etag)
+	ctx.Header("Cache-Control", "public, max-age="+strconv.Itoa(int(m.ttl.Seconds())))
+	ctx.Header("Vary", "Accept")
+	if match := ctx.GetHeader("If-None-Match"); match != "" && (match == entry.etag || match == "*") {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+	ctx.Data(http.StatusOK, "application/json; charset=utf-8", entry.body)
+}
+
+// get returns a fresh entry for key, rebuilding it if needed
+func (m *metadataCache) get(key string, build func() interface{}) (*cachedMetadata, error) {
+	registry := models.LanguageRegistryFingerprint()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if entry, ok := m.entries[key]; ok && entry.registry == registry && time.Now().Before(entry.expiresAt) {
+		return entry, nil
+	}
+
+	body, err := json.Marshal(build())
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(body)
+	entry := &cachedMetadata{
+		body:      body,
+		etag:      `W/"` + hex.EncodeToString(sum[:16]) + `"`,
+		registry:  registry,
+		expiresAt: time.Now().Add(m.ttl),
+	}
+	if m.ttl > 0 {
+		m.entries[key] = entry
+	}
+	return entry, nil
+}
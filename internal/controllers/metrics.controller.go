@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"ignis/internal/middleware"
+	"ignis/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsController exposes operational metrics in the Prometheus text format
+type MetricsController struct {
+	jobService *services.JobService
+}
+
+// NewMetricsController creates a new instance of MetricsController
+func NewMetricsController(jobService *services.JobService) *MetricsController {
+	return &MetricsController{
+		jobService: jobService,
+	}
+}
+
+// GetMetrics handles GET /metrics - Prometheus scrape endpoint
+func (c *MetricsController) GetMetrics(ctx *gin.Context) {
+	counts, err := c.jobService.GetInFlightCounts()
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, "# failed to collect metrics, error_id %s\n", logInternalError(ctx, err))
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("# HELP ignis_jobs_in_flight Jobs that have not finished yet, by status.\n")
+	b.WriteString("# TYPE ignis_jobs_in_flight gauge\n")
+	fmt.Fprintf(&b, "ignis_jobs_in_flight{status=\"received\"} %d\n", counts.Received)
+	fmt.Fprintf(&b, "ignis_jobs_in_flight{status=\"running\"} %d\n", counts.Running)
+	middleware.WriteRequestMetrics(&b)
+
+	ctx.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(b.String()))
+}
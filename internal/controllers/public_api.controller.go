@@ -1,55 +1,121 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
+	"ignis/internal/config"
 	"ignis/internal/middleware"
 	"ignis/internal/models"
 	"ignis/internal/services"
 
 	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
 )
 
 // PublicAPIController handles public API requests for external consumers
 type PublicAPIController struct {
-	jobService *services.JobService
+	jobService   *services.JobService
+	rateLimiter  *services.RateLimiterService
+	batchPartial bool // Default for accepting a batch up to the remaining quota instead of rejecting it
+	metadata     *metadataCache
 }
 
 // NewPublicAPIController creates a new instance of PublicAPIController
-func NewPublicAPIController(jobService *services.JobService) *PublicAPIController {
+func NewPublicAPIController(jobService *services.JobService, rateLimiter *services.RateLimiterService) *PublicAPIController {
 	return &PublicAPIController{
-		jobService: jobService,
+		jobService:   jobService,
+		rateLimiter:  rateLimiter,
+		batchPartial: config.GetEnvBool("BATCH_QUOTA_PARTIAL", false),
+		metadata:     newMetadataCache(config.GetEnvDuration("METADATA_CACHE_TTL", time.Minute)),
 	}
 }
 
 // ExecuteCodeRequest represents the public API request for code execution
 type ExecuteCodeRequest struct {
-	Language string `json:"language" binding:"required,min=1,max=50"`
-	Code     string `json:"code" binding:"required,min=1"`
+	Language      string             `json:"language" binding:"required,min=1,max=50"`
+	Code          string             `json:"code" binding:"required,min=1"`
+	Name          string             `json:"name,omitempty" binding:"max=100"`
+	Description   string             `json:"description,omitempty" binding:"max=500"`
+	Tags          models.JobTags     `json:"tags,omitempty" binding:"max=20,dive,min=1,max=50"`
+	Metadata      models.JobMetadata `json:"metadata,omitempty" binding:"max=20,dive,keys,min=1,max=50,endkeys,max=500"`
+	Args          models.JobArgs     `json:"args,omitempty" binding:"max=50,dive,max=1024"`
+	WorkerVersion string             `json:"worker_version,omitempty" binding:"max=50"`
+	Deadline      *time.Time         `json:"deadline,omitempty"`
+	RunAt         *time.Time         `json:"run_at,omitempty"`
+	Priority      models.JobPriority `json:"priority,omitempty" binding:"omitempty,oneof=low normal high"`
+	Ephemeral     bool               `json:"ephemeral,omitempty"`
 }
 
-// ExecuteCodeResponse represents the public API response for code execution
-type ExecuteCodeResponse struct {
-	JobID    string           `json:"job_id"`
-	Language string           `json:"language"`
-	Status   models.JobStatus `json:"status"`
-	Message  string           `json:"message,omitempty"`
+// BatchExecuteRequest represents the public API request for submitting several jobs at once
+type BatchExecuteRequest struct {
+	Jobs    []ExecuteCodeRequest `json:"jobs" binding:"required,min=1,max=50,dive"`
+	Partial *bool                `json:"partial,omitempty"` // Accept up to the remaining quota instead of rejecting the batch
 }
 
-// JobStatusResponse represents the public API response for job status
-type JobStatusResponse struct {
+// BatchItemError reports why a batch item was not submitted
+type BatchItemError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// BatchExecuteResponse lists which batch items became jobs, by their index in the request
+type BatchExecuteResponse struct {
+	Accepted []BatchAcceptedJob `json:"accepted"`
+	Rejected []BatchItemError   `json:"rejected"`
+}
+
+// BatchAcceptedJob is a batch item that was submitted
+type BatchAcceptedJob struct {
+	Index int `json:"index"`
+	ExecuteCodeResponse
+}
+
+// ExecuteCodeResponse represents the public API response for code execution
+type ExecuteCodeResponse struct {
 	JobID        string           `json:"job_id"`
 	Language     string           `json:"language"`
+	Name         string           `json:"name,omitempty"`
+	CodeHash     string           `json:"code_hash"`
 	Status       models.JobStatus `json:"status"`
+	Dispatched   bool             `json:"dispatched"` // NATS confirmed it received the job
+	DispatchedAt *time.Time       `json:"dispatched_at,omitempty"`
 	Message      string           `json:"message,omitempty"`
-	Error        string           `json:"error,omitempty"`
-	StdOut       string           `json:"stdout,omitempty"`
-	StdErr       string           `json:"stderr,omitempty"`
-	ExecDuration int              `json:"exec_duration,omitempty"`
-	MemUsage     int64            `json:"mem_usage,omitempty"`
-	CreatedAt    string           `json:"created_at"`
-	UpdatedAt    string           `json:"updated_at"`
+	Warning      string           `json:"warning,omitempty"`
+}
+
+// JobStatusResponse represents the public API response for job status
+type JobStatusResponse struct {
+	JobID                 string             `json:"job_id"`
+	Language              string             `json:"language"`
+	Name                  string             `json:"name,omitempty"`
+	Description           string             `json:"description,omitempty"`
+	Tags                  models.JobTags     `json:"tags,omitempty"`
+	Metadata              models.JobMetadata `json:"metadata,omitempty"`
+	Args                  models.JobArgs     `json:"args,omitempty"`
+	WorkerVersion         string             `json:"worker_version,omitempty"`
+	CodeHash              string             `json:"code_hash,omitempty"`
+	ParentJobID           string             `json:"parent_job_id,omitempty"` // Job this one re-runs
+	Status                models.JobStatus   `json:"status"`
+	Message               string             `json:"message,omitempty"`
+	Error                 string             `json:"error,omitempty"`
+	StdOut                string             `json:"stdout,omitempty"`
+	StdErr                string             `json:"stderr,omitempty"`
+	OutputStored          bool               `json:"output_stored,omitempty"`
+	ExecDuration          int                `json:"exec_duration,omitempty"`
+	CompileDuration       int                `json:"compile_duration,omitempty"`
+	RunDuration           int                `json:"run_duration,omitempty"`
+	MemUsage              int64              `json:"mem_usage,omitempty"`
+	StartedAt             string             `json:"started_at,omitempty"`
+	FinishedAt            string             `json:"finished_at,omitempty"`
+	RunAt                 string             `json:"run_at,omitempty"`
+	QueuePosition         int64              `json:"queue_position,omitempty"`
+	EstimatedCompletionAt string             `json:"estimated_completion_at,omitempty"`
+	CreatedAt             string             `json:"created_at"`
+	UpdatedAt             string             `json:"updated_at"`
 }
 
 // ExecuteCode handles POST /public/execute - Submit code for execution
@@ -67,28 +133,131 @@ func (c *PublicAPIController) ExecuteCode(ctx *gin.Context) {
 		return
 	}
 
-	// Convert to job create request
-	jobReq := models.JobCreateRequest{
-		Language: req.Language,
-		Code:     req.Code,
+	if c.reserveJobQuota(apiKey, 1, false) == 0 {
+		ctx.JSON(http.StatusTooManyRequests, gin.H{"error": "Job submission quota exceeded"})
+		return
 	}
 
 	// Create job using the API key's associated user ID
-	job, err := c.jobService.CreateJob(jobReq, apiKey.ClerkUserID)
+	jobReq := req.toJobCreateRequest()
+	jobReq.APIKeyID = &apiKey.ID
+	jobReq.Priority = apiKey.JobPriority(jobReq.Priority)
+	job, err := c.jobService.CreateJob(ctx.Request.Context(), jobReq, apiKey.ClerkUserID)
 	if err != nil {
+		c.returnJobQuota(apiKey, 1)
+		if respondIfTimedOut(ctx, err) || respondIfOverCapacity(ctx, err, c.jobService.CapacityRetryAfter()) || respondIfPolicyLimit(ctx, err) || respondIfCoolingDown(ctx, err) {
+			return
+		}
+		respondServiceError(ctx, err)
+		return
+	}
+
+	respondJSON(ctx, http.StatusCreated, gin.H{"data": toExecuteCodeResponse(job)})
+}
+
+// ExecuteBatch handles POST /public/execute/batch - Submit several jobs against the key's quota at once.
+// The whole batch is checked against the remaining quota up front, so it is either rejected outright
+// or, in partial mode, exactly the items that fit are accepted; the quota is never consumed piecemeal.
+// Items that fail to submit after the check are given back to the quota.
+func (c *PublicAPIController) ExecuteBatch(ctx *gin.Context) {
+	apiKey, exists := middleware.GetAPIKeyFromContext(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "API key authentication required"})
+		return
+	}
+
+	var req BatchExecuteRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Return simplified response for public API
-	response := ExecuteCodeResponse{
-		JobID:    job.JobID,
-		Language: job.Language,
-		Status:   job.Status,
-		Message:  "Code submitted for execution",
+	partial := c.batchPartial
+	if req.Partial != nil {
+		partial = *req.Partial
+	}
+
+	granted := c.reserveJobQuota(apiKey, len(req.Jobs), partial)
+	if granted == 0 {
+		ctx.JSON(http.StatusTooManyRequests, gin.H{
+			"error":     "Job submission quota exceeded for this batch",
+			"requested": len(req.Jobs),
+			"limit":     apiKey.RateLimit,
+			"window":    "1 minute",
+		})
+		return
+	}
+
+	response := BatchExecuteResponse{
+		Accepted: make([]BatchAcceptedJob, 0, granted),
+		Rejected: make([]BatchItemError, 0),
+	}
+	for i, item := range req.Jobs {
+		if i >= granted {
+			response.Rejected = append(response.Rejected, BatchItemError{Index: i, Error: "job submission quota exceeded"})
+			continue
+		}
+
+		jobReq := item.toJobCreateRequest()
+		jobReq.APIKeyID = &apiKey.ID
+		jobReq.Priority = apiKey.JobPriority(jobReq.Priority)
+		job, err := c.jobService.CreateJob(ctx.Request.Context(), jobReq, apiKey.ClerkUserID)
+		if err != nil {
+			response.Rejected = append(response.Rejected, BatchItemError{Index: i, Error: clientErrorMessage(ctx, err)})
+			continue
+		}
+		response.Accepted = append(response.Accepted, BatchAcceptedJob{Index: i, ExecuteCodeResponse: toExecuteCodeResponse(job)})
+	}
+	c.returnJobQuota(apiKey, granted-len(response.Accepted))
+
+	respondJSON(ctx, http.StatusCreated, gin.H{"data": response})
+}
+
+// reserveJobQuota consumes n job submissions from the API key's per-minute quota and returns how many
+// were granted: all or none, or with partial as many as remain. Unlimited keys are always granted.
+func (c *PublicAPIController) reserveJobQuota(apiKey *models.APIKey, n int, partial bool) int {
+	if c.rateLimiter == nil || apiKey.Unlimited {
+		return n
+	}
+
+	granted, err := c.rateLimiter.AllowN(services.GetAPIKeyJobQuotaKey(strconv.Itoa(int(apiKey.ID))), n, apiKey.RateLimit, time.Minute, partial)
+	if err != nil {
+		// Fail open like the request rate limiter; the per-request limit still applies
+		log.WithError(err).Error("Job quota check failed")
+		return n
+	}
+	return granted
+}
+
+// returnJobQuota gives n job submissions reserved by reserveJobQuota back to the API key's quota
+func (c *PublicAPIController) returnJobQuota(apiKey *models.APIKey, n int) {
+	if c.rateLimiter == nil || apiKey.Unlimited {
+		return
 	}
 
-	ctx.JSON(http.StatusCreated, gin.H{"data": response})
+	if err := c.rateLimiter.ReturnN(services.GetAPIKeyJobQuotaKey(strconv.Itoa(int(apiKey.ID))), n); err != nil {
+		log.WithError(err).Error("Failed to return job quota")
+	}
+}
+
+// EstimateCost handles POST /public/execute/estimate - Preview the cost of a submission without running it
+func (c *PublicAPIController) EstimateCost(ctx *gin.Context) {
+	var req ExecuteCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	estimate, err := c.jobService.EstimateCost(models.JobCreateRequest{
+		Language: req.Language,
+		Code:     req.Code,
+	})
+	if err != nil {
+		respondServiceError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": estimate})
 }
 
 // GetJobStatus handles GET /public/jobs/:job_id - Get job execution status and results
@@ -107,8 +276,11 @@ func (c *PublicAPIController) GetJobStatus(ctx *gin.Context) {
 	}
 
 	// Get job by job ID
-	job, err := c.jobService.GetJobByJobID(jobID)
+	job, err := c.jobService.GetJobByJobID(ctx.Request.Context(), jobID)
 	if err != nil {
+		if respondIfTimedOut(ctx, err) {
+			return
+		}
 		ctx.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
 		return
 	}
@@ -120,24 +292,172 @@ func (c *PublicAPIController) GetJobStatus(ctx *gin.Context) {
 	}
 
 	// Return simplified response for public API
-	response := JobStatusResponse{
-		JobID:        job.JobID,
-		Language:     job.Language,
-		Status:       job.Status,
-		Message:      job.Message,
-		Error:        job.Error,
-		StdOut:       job.StdOut,
-		StdErr:       job.StdErr,
-		ExecDuration: job.ExecDuration,
-		MemUsage:     job.MemUsage,
-		CreatedAt:    job.CreatedAt.Format("2006-01-02T15:04:05Z"),
-		UpdatedAt:    job.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+	response := toJobStatusResponse(*job, middleware.GetTimezoneFromContext(ctx))
+
+	respondJSON(ctx, http.StatusOK, gin.H{"data": response})
+}
+
+// GetScheduledJobs handles GET /public/jobs/scheduled - Jobs waiting for their run time
+func (c *PublicAPIController) GetScheduledJobs(ctx *gin.Context) {
+	apiKey, exists := middleware.GetAPIKeyFromContext(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "API key authentication required"})
+		return
+	}
+
+	jobs, err := c.jobService.GetScheduledJobs(apiKey.ClerkUserID)
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+
+	loc := middleware.GetTimezoneFromContext(ctx)
+	responses := make([]JobStatusResponse, 0, len(jobs))
+	for _, job := range jobs {
+		responses = append(responses, toJobStatusResponse(job, loc))
+	}
+
+	respondJSON(ctx, http.StatusOK, gin.H{"data": responses})
+}
+
+// CancelScheduledJob handles DELETE /public/jobs/scheduled/:job_id - Cancel a job before it runs
+func (c *PublicAPIController) CancelScheduledJob(ctx *gin.Context) {
+	apiKey, exists := middleware.GetAPIKeyFromContext(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "API key authentication required"})
+		return
+	}
+
+	job, err := c.jobService.CancelScheduledJob(ctx.Param("job_id"), apiKey.ClerkUserID)
+	if err != nil {
+		if errors.Is(err, services.ErrJobNotScheduled) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "Job is no longer scheduled"})
+			return
+		}
+		respondServiceError(ctx, err)
+		return
+	}
+
+	respondJSON(ctx, http.StatusOK, gin.H{"data": toJobStatusResponse(*job, middleware.GetTimezoneFromContext(ctx))})
+}
+
+// CancelJob handles POST /public/jobs/:job_id/cancel - cancels a job that hasn't finished yet
+func (c *PublicAPIController) CancelJob(ctx *gin.Context) {
+	apiKey, exists := middleware.GetAPIKeyFromContext(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "API key authentication required"})
+		return
+	}
+
+	job, err := c.jobService.CancelJob(ctx.Param("job_id"), apiKey.ClerkUserID)
+	if err != nil {
+		if errors.Is(err, services.ErrJobNotCancellable) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "Job has already finished"})
+			return
+		}
+		respondServiceError(ctx, err)
+		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"data": response})
+	respondJSON(ctx, http.StatusOK, gin.H{"data": toJobStatusResponse(*job, middleware.GetTimezoneFromContext(ctx))})
 }
 
-// GetMyJobs handles GET /public/jobs - Get all jobs for the authenticated API key user
+// BatchJobStatusRequest represents the public API request for polling several jobs at once
+type BatchJobStatusRequest struct {
+	JobIDs       []string   `json:"job_ids" binding:"max=100"`
+	UpdatedSince *time.Time `json:"updated_since,omitempty"` // Only return jobs changed after this time
+	AfterJobID   string     `json:"after_job_id,omitempty"`  // With updated_since, also return jobs changed at that time sorting after this ID
+}
+
+// GetJobStatuses handles POST /public/jobs/status - Poll many jobs, optionally only those changed since the last poll
+func (c *PublicAPIController) GetJobStatuses(ctx *gin.Context) {
+	apiKey, exists := middleware.GetAPIKeyFromContext(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "API key authentication required"})
+		return
+	}
+
+	var req BatchJobStatusRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.JobIDs) == 0 && req.UpdatedSince == nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Provide job_ids, updated_since, or both"})
+		return
+	}
+
+	var after *models.JobStatusCursor
+	if req.UpdatedSince != nil {
+		after = &models.JobStatusCursor{UpdatedAt: *req.UpdatedSince, JobID: req.AfterJobID}
+	}
+
+	jobs, hasMore, err := c.jobService.GetJobStatuses(apiKey.ClerkUserID, req.JobIDs, after)
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+
+	loc := middleware.GetTimezoneFromContext(ctx)
+	responses := make([]JobStatusResponse, 0, len(jobs))
+	for _, job := range jobs {
+		responses = append(responses, toJobStatusResponse(job, loc))
+	}
+
+	nextUpdatedSince, nextAfterJobID := nextJobStatusCursor(jobs, req.UpdatedSince, req.AfterJobID)
+	respondJSON(ctx, http.StatusOK, gin.H{
+		"data":               responses,
+		"next_updated_since": nextUpdatedSince,
+		"next_after_job_id":  nextAfterJobID,
+		"has_more":           hasMore,
+	})
+}
+
+// nextJobStatusCursor hands back the last job returned as the cursor for the next poll; it stays
+// put when nothing changed. The update time is formatted at full precision up front because
+// respondJSON's timestamp localization drops sub-second digits, and a truncated cursor would
+// return every job updated later in the same second again.
+func nextJobStatusCursor(jobs []models.JobResponse, updatedSince *time.Time, afterJobID string) (*string, string) {
+	if len(jobs) > 0 {
+		last := jobs[len(jobs)-1]
+		updatedSince, afterJobID = &last.UpdatedAt, last.JobID
+	}
+	if updatedSince == nil {
+		return nil, afterJobID
+	}
+	formatted := updatedSince.UTC().Format(time.RFC3339Nano)
+	return &formatted, afterJobID
+}
+
+// GetJobCountsByLanguage handles GET /public/jobs/stats/by-language - Job counts per language, optionally since a time
+func (c *PublicAPIController) GetJobCountsByLanguage(ctx *gin.Context) {
+	apiKey, exists := middleware.GetAPIKeyFromContext(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "API key authentication required"})
+		return
+	}
+
+	var since *time.Time
+	if sinceParam := ctx.Query("since"); sinceParam != "" {
+		parsed, err := time.Parse(time.RFC3339, sinceParam)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid since, expected RFC3339"})
+			return
+		}
+		since = &parsed
+	}
+
+	counts, err := c.jobService.CountByLanguage(apiKey.ClerkUserID, since)
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+
+	respondJSON(ctx, http.StatusOK, gin.H{"data": counts})
+}
+
+// GetMyJobs handles GET /public/jobs - Get all jobs for the authenticated API key user, or with
+// scope=key only those submitted with the authenticating key
 func (c *PublicAPIController) GetMyJobs(ctx *gin.Context) {
 	// Get API key data from context (API key auth required)
 	apiKey, exists := middleware.GetAPIKeyFromContext(ctx)
@@ -162,74 +482,188 @@ func (c *PublicAPIController) GetMyJobs(ctx *gin.Context) {
 		}
 	}
 
-	jobs, err := c.jobService.GetJobsByClerkUserID(apiKey.ClerkUserID)
+	var apiKeyID *uint
+	switch scope := ctx.DefaultQuery("scope", "user"); scope {
+	case "user":
+	case "key":
+		apiKeyID = &apiKey.ID
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "scope must be one of: user, key"})
+		return
+	}
+	filter := models.JobListFilter{APIKeyID: apiKeyID, ParentJobID: ctx.Query("parent_job_id")}
+	jobs, total, err := c.jobService.SearchJobs(apiKey.ClerkUserID, filter, limit, offset)
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+
+	// Convert to simplified response format
+	loc := middleware.GetTimezoneFromContext(ctx)
+	responses := make([]JobStatusResponse, 0, len(jobs))
+	for _, job := range jobs {
+		responses = append(responses, toJobStatusResponse(job, loc))
+	}
+
+	respondPage(ctx, responses, total, len(responses), limit, offset)
+}
+
+// GetAPIStatus handles GET /public/status - Get API status and basic info
+func (c *PublicAPIController) GetAPIStatus(ctx *gin.Context) {
+	// This endpoint can be used to check API connectivity and get basic info
+	c.metadata.serve(ctx, "status", func() interface{} {
+		return gin.H{
+			"status":      "operational",
+			"version":     "1.0.0",
+			"service":     "Ignis Code Execution API",
+			"description": "Submit code for execution and retrieve results",
+			"endpoints": gin.H{
+				"execute":   "POST /public/execute",
+				"status":    "GET /public/jobs/{job_id}",
+				"jobs":      "GET /public/jobs",
+				"languages": "GET /public/languages",
+			},
+			"supported_languages": models.LanguageNames(),
+		}
+	})
+}
+
+// GetLanguages handles GET /public/languages - the language registry with accepted aliases
+func (c *PublicAPIController) GetLanguages(ctx *gin.Context) {
+	c.metadata.serve(ctx, "languages", func() interface{} {
+		return gin.H{"data": models.SupportedLanguages}
+	})
+}
+
+// GetWorkerVersions handles GET /public/worker-versions - versions jobs can be pinned to with worker_version
+func (c *PublicAPIController) GetWorkerVersions(ctx *gin.Context) {
+	respondJSON(ctx, http.StatusOK, gin.H{"data": c.jobService.AvailableWorkerVersions()})
+}
+
+// GetLanguageLeaderboard handles GET /public/stats/languages - Anonymized job counts per language
+func (c *PublicAPIController) GetLanguageLeaderboard(ctx *gin.Context) {
+	days := 30
+	if daysParam := ctx.Query("days"); daysParam != "" {
+		if days = parseInt(daysParam, 1, 365); days < 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 365"})
+			return
+		}
+	}
+
+	usage, err := c.jobService.GetLanguageLeaderboard(days)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondInternalError(ctx, err)
 		return
 	}
 
-	// Apply pagination
-	total := len(jobs)
-	start := offset
-	end := offset + limit
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": usage,
+		"days": days,
+	})
+}
 
-	if start > total {
-		start = total
+// GetStatsOverview handles GET /public/stats/overview - Anonymized system-wide throughput for a status page
+func (c *PublicAPIController) GetStatsOverview(ctx *gin.Context) {
+	hours := 1
+	if hoursParam := ctx.Query("hours"); hoursParam != "" {
+		if hours = parseInt(hoursParam, 1, 168); hours < 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "hours must be between 1 and 168"})
+			return
+		}
 	}
-	if end > total {
-		end = total
+
+	overview, err := c.jobService.GetStatsOverview(hours)
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
 	}
 
-	paginatedJobs := jobs[start:end]
+	respondJSON(ctx, http.StatusOK, gin.H{"data": overview})
+}
 
-	// Convert to simplified response format
-	var responses []JobStatusResponse
-	for _, job := range paginatedJobs {
-		responses = append(responses, JobStatusResponse{
-			JobID:        job.JobID,
-			Language:     job.Language,
-			Status:       job.Status,
-			Message:      job.Message,
-			Error:        job.Error,
-			StdOut:       job.StdOut,
-			StdErr:       job.StdErr,
-			ExecDuration: job.ExecDuration,
-			MemUsage:     job.MemUsage,
-			CreatedAt:    job.CreatedAt.Format("2006-01-02T15:04:05Z"),
-			UpdatedAt:    job.UpdatedAt.Format("2006-01-02T15:04:05Z"),
-		})
+// toJobCreateRequest converts a public API request to a job create request
+func (r ExecuteCodeRequest) toJobCreateRequest() models.JobCreateRequest {
+	return models.JobCreateRequest{
+		Language:      r.Language,
+		Code:          r.Code,
+		Name:          r.Name,
+		Description:   r.Description,
+		Tags:          r.Tags,
+		Metadata:      r.Metadata,
+		Args:          r.Args,
+		WorkerVersion: r.WorkerVersion,
+		Deadline:      r.Deadline,
+		RunAt:         r.RunAt,
+		Priority:      r.Priority,
+		Ephemeral:     r.Ephemeral,
 	}
+}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"data": responses,
-		"pagination": gin.H{
-			"total":  total,
-			"limit":  limit,
-			"offset": offset,
-			"count":  len(responses),
-		},
-	})
+// toExecuteCodeResponse converts a created job to the simplified public API format
+func toExecuteCodeResponse(job *models.JobResponse) ExecuteCodeResponse {
+	message := "Code submitted for execution"
+	if job.Status == models.JobStatusScheduled {
+		message = "Code scheduled for execution"
+	}
+
+	return ExecuteCodeResponse{
+		JobID:        job.JobID,
+		Language:     job.Language,
+		Name:         job.Name,
+		CodeHash:     job.CodeHash,
+		Status:       job.Status,
+		Dispatched:   job.Dispatched,
+		DispatchedAt: job.DispatchedAt,
+		Message:      message,
+		Warning:      job.Warning,
+	}
 }
 
-// GetAPIStatus handles GET /public/status - Get API status and basic info
-func (c *PublicAPIController) GetAPIStatus(ctx *gin.Context) {
-	// This endpoint can be used to check API connectivity and get basic info
-	response := gin.H{
-		"status":      "operational",
-		"version":     "1.0.0",
-		"service":     "Ignis Code Execution API",
-		"description": "Submit code for execution and retrieve results",
-		"endpoints": gin.H{
-			"execute": "POST /public/execute",
-			"status":  "GET /public/jobs/{job_id}",
-			"jobs":    "GET /public/jobs",
-		},
-		"supported_languages": []string{
-			"python", "go", 
-		},
-	}
-
-	ctx.JSON(http.StatusOK, response)
+// toJobStatusResponse converts a job to the simplified public API format
+func toJobStatusResponse(job models.JobResponse, loc *time.Location) JobStatusResponse {
+	var startedAt, finishedAt, runAt, estimatedCompletionAt string
+	if job.StartedAt != nil {
+		startedAt = models.FormatTimestamp(*job.StartedAt, loc)
+	}
+	if job.FinishedAt != nil {
+		finishedAt = models.FormatTimestamp(*job.FinishedAt, loc)
+	}
+	if job.RunAt != nil {
+		runAt = models.FormatTimestamp(*job.RunAt, loc)
+	}
+	if job.EstimatedCompletionAt != nil {
+		estimatedCompletionAt = models.FormatTimestamp(*job.EstimatedCompletionAt, loc)
+	}
+
+	return JobStatusResponse{
+		JobID:                 job.JobID,
+		Language:              job.Language,
+		Name:                  job.Name,
+		Description:           job.Description,
+		Tags:                  job.Tags,
+		Metadata:              job.Metadata,
+		Args:                  job.Args,
+		WorkerVersion:         job.WorkerVersion,
+		CodeHash:              job.CodeHash,
+		ParentJobID:           job.ParentJobID,
+		Status:                job.Status,
+		Message:               job.Message,
+		Error:                 job.Error,
+		StdOut:                job.StdOut,
+		StdErr:                job.StdErr,
+		OutputStored:          job.OutputStored,
+		ExecDuration:          job.ExecDuration,
+		CompileDuration:       job.CompileDuration,
+		RunDuration:           job.RunDuration,
+		MemUsage:              job.MemUsage,
+		StartedAt:             startedAt,
+		FinishedAt:            finishedAt,
+		RunAt:                 runAt,
+		QueuePosition:         job.QueuePosition,
+		EstimatedCompletionAt: estimatedCompletionAt,
+		CreatedAt:             models.FormatTimestamp(job.CreatedAt, loc),
+		UpdatedAt:             models.FormatTimestamp(job.UpdatedAt, loc),
+	}
 }
 
 // Helper function to parse integer with bounds
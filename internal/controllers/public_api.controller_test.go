@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"ignis/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pollJobStatuses mimics GetJobStatuses' query: jobs after the cursor by update time then job ID,
+// at most pageSize of them
+func pollJobStatuses(jobs []models.JobResponse, after *models.JobStatusCursor, pageSize int) ([]models.JobResponse, bool) {
+	page := make([]models.JobResponse, 0)
+	for _, job := range jobs {
+		if after != nil {
+			if job.UpdatedAt.Before(after.UpdatedAt) {
+				continue
+			}
+			if job.UpdatedAt.Equal(after.UpdatedAt) && (after.JobID == "" || job.JobID <= after.JobID) {
+				continue
+			}
+		}
+		page = append(page, job)
+	}
+	sort.Slice(page, func(i, j int) bool {
+		if !page[i].UpdatedAt.Equal(page[j].UpdatedAt) {
+			return page[i].UpdatedAt.Before(page[j].UpdatedAt)
+		}
+		return page[i].JobID < page[j].JobID
+	})
+	if len(page) > pageSize {
+		return page[:pageSize], true
+	}
+	return page, false
+}
+
+func TestJobStatusCursorPagesThroughOneSecond(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const pageSize = 500
+	second := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	jobs := make([]models.JobResponse, 0, 1203)
+	for i := 0; i < 1200; i++ {
+		jobs = append(jobs, models.JobResponse{
+			JobID:     fmt.Sprintf("job_%04d", i),
+			UpdatedAt: second.Add(time.Duration(i) * time.Microsecond),
+		})
+	}
+	// A few jobs share an update time, so the job ID has to break the tie
+	for i := 0; i < 3; i++ {
+		jobs = append(jobs, models.JobResponse{JobID: fmt.Sprintf("job_tie_%d", i), UpdatedAt: second.Add(600 * time.Microsecond)})
+	}
+
+	seen := make(map[string]int)
+	after := &models.JobStatusCursor{UpdatedAt: second.Add(-time.Second)}
+	for polls := 0; ; polls++ {
+		if polls > 10 {
+			t.Fatalf("still paging after %d polls", polls)
+		}
+
+		page, hasMore := pollJobStatuses(jobs, after, pageSize)
+		for _, job := range page {
+			seen[job.JobID]++
+		}
+
+		// Round-trip the cursor through the response the way a client would
+		nextUpdatedSince, nextAfterJobID := nextJobStatusCursor(page, &after.UpdatedAt, after.JobID)
+		recorder := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(recorder)
+		respondJSON(ctx, http.StatusOK, gin.H{
+			"next_updated_since": nextUpdatedSince,
+			"next_after_job_id":  nextAfterJobID,
+		})
+		var body struct {
+			NextUpdatedSince time.Time `json:"next_updated_since"`
+			NextAfterJobID   string    `json:"next_after_job_id"`
+		}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		after = &models.JobStatusCursor{UpdatedAt: body.NextUpdatedSince, JobID: body.NextAfterJobID}
+
+		if !hasMore {
+			break
+		}
+	}
+
+	if len(seen) != len(jobs) {
+		t.Errorf("expected all %d jobs, saw %d", len(jobs), len(seen))
+	}
+	for jobID, count := range seen {
+		if count != 1 {
+			t.Errorf("job %s returned %d times", jobID, count)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ignis/internal/middleware"
+	"ignis/internal/models"
+	"ignis/internal/services"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// logInternalError logs err with the request details under a new error ID and returns the ID,
+// which clients can quote to support instead of seeing the underlying error
+func logInternalError(ctx *gin.Context, err error) string {
+	id := make([]byte, 8)
+	errorID := "unknown"
+	if _, randErr := rand.Read(id); randErr == nil {
+		errorID = hex.EncodeToString(id)
+	}
+
+	log.WithError(err).WithFields(log.Fields{
+		"error_id":   errorID,
+		"request_id": middleware.GetRequestIDFromContext(ctx),
+		"method":     ctx.Request.Method,
+		"route":      ctx.FullPath(),
+	}).Error("Request failed with an internal error")
+	return errorID
+}
+
+// respondInternalError writes a 500 with a generic message and the error ID err was logged
+// under, so database errors and other internals never reach the client
+func respondInternalError(ctx *gin.Context, err error) {
+	errorID := logInternalError(ctx, err)
+	ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error", "error_id": errorID})
+}
+
+// respondServiceError writes the response for an error returned by a service: 404 for missing
+// records, 400 for invalid input, and a masked 500 for anything else
+func respondServiceError(ctx *gin.Context, err error) {
+	var inputErr *services.InvalidInputError
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.As(err, &inputErr):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		respondInternalError(ctx, err)
+	}
+}
+
+// clientErrorMessage returns err's message when it describes a problem with the request; anything
+// else is logged and replaced by a generic message with its error ID
+func clientErrorMessage(ctx *gin.Context, err error) string {
+	if services.IsClientError(err) {
+		return err.Error()
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "Request timed out"
+	}
+	return "Internal server error (error_id " + logInternalError(ctx, err) + ")"
+}
+
+// respondIfTimedOut writes a 504 when err was caused by the request deadline
+// and reports whether a response was written
+func respondIfTimedOut(ctx *gin.Context, err error) bool {
+	if !errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	ctx.JSON(http.StatusGatewayTimeout, gin.H{"error": "Request timed out"})
+	return true
+}
+
+// respondIfOverCapacity writes a 503 with Retry-After when err is services.ErrJobCapacityReached
+// and reports whether a response was written
+func respondIfOverCapacity(ctx *gin.Context, err error, retryAfter time.Duration) bool {
+	if !errors.Is(err, services.ErrJobCapacityReached) {
+		return false
+	}
+	ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	return true
+}
+
+// respondIfPolicyLimit writes a 403 when err is services.ErrPolicyLimitReached, i.e. the user's
+// tier doesn't allow the action, and reports whether a response was written
+func respondIfPolicyLimit(ctx *gin.Context, err error) bool {
+	if !errors.Is(err, services.ErrPolicyLimitReached) {
+		return false
+	}
+	ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	return true
+}
+
+// respondIfCoolingDown writes a 429 with Retry-After when err is a services.SubmissionCooldownError,
+// i.e. the same code was just submitted, and reports whether a response was written
+func respondIfCoolingDown(ctx *gin.Context, err error) bool {
+	var cooldownErr *services.SubmissionCooldownError
+	if !errors.As(err, &cooldownErr) {
+		return false
+	}
+	ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(cooldownErr.RetryAfter.Seconds()))))
+	ctx.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	return true
+}
+
+// paginationEnvelope describes a page of an offset-paginated listing; has_more tells clients
+// whether another page follows without comparing offsets to the total themselves
+func paginationEnvelope(total int64, count int, limit int, offset int) gin.H {
+	return gin.H{
+		"total":    total,
+		"count":    count,
+		"limit":    limit,
+		"offset":   offset,
+		"has_more": int64(offset+count) < total,
+	}
+}
+
+// respondPage writes a page of an offset-paginated listing in the shared {data, pagination} envelope
+func respondPage(ctx *gin.Context, data interface{}, total int64, count int, limit int, offset int) {
+	respondJSON(ctx, http.StatusOK, gin.H{
+		"data":       data,
+		"pagination": paginationEnvelope(total, count, limit, offset),
+	})
+}
+
+// respondJSON writes obj as JSON after rendering its timestamps as RFC3339 in the
+// caller's requested timezone
+func respondJSON(ctx *gin.Context, status int, obj interface{}) {
+	models.LocalizeTimestamps(obj, middleware.GetTimezoneFromContext(ctx))
+	ctx.JSON(status, obj)
+}
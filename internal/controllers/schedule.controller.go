@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"ignis/internal/middleware"
+	"ignis/internal/models"
+	"ignis/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ScheduleController handles public API requests for recurring job schedules
+type ScheduleController struct {
+	jobService *services.JobService
+}
+
+// NewScheduleController creates a new instance of ScheduleController
+func NewScheduleController(jobService *services.JobService) *ScheduleController {
+	return &ScheduleController{
+		jobService: jobService,
+	}
+}
+
+// CreateSchedule handles POST /public/schedules
+func (c *ScheduleController) CreateSchedule(ctx *gin.Context) {
+	apiKey, exists := middleware.GetAPIKeyFromContext(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "API key authentication required"})
+		return
+	}
+
+	var req models.JobScheduleCreateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schedule, err := c.jobService.CreateSchedule(req, apiKey.ClerkUserID)
+	if err != nil {
+		respondServiceError(ctx, err)
+		return
+	}
+
+	respondJSON(ctx, http.StatusCreated, gin.H{"data": schedule})
+}
+
+// GetSchedules handles GET /public/schedules
+func (c *ScheduleController) GetSchedules(ctx *gin.Context) {
+	apiKey, exists := middleware.GetAPIKeyFromContext(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "API key authentication required"})
+		return
+	}
+
+	schedules, err := c.jobService.GetSchedules(apiKey.ClerkUserID)
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+
+	respondJSON(ctx, http.StatusOK, gin.H{"data": schedules})
+}
+
+// GetSchedule handles GET /public/schedules/:id
+func (c *ScheduleController) GetSchedule(ctx *gin.Context) {
+	apiKey, exists := middleware.GetAPIKeyFromContext(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "API key authentication required"})
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule ID"})
+		return
+	}
+
+	schedule, err := c.jobService.GetSchedule(uint(id), apiKey.ClerkUserID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Schedule not found"})
+		return
+	}
+
+	respondJSON(ctx, http.StatusOK, gin.H{"data": schedule})
+}
+
+// UpdateSchedule handles PATCH /public/schedules/:id
+func (c *ScheduleController) UpdateSchedule(ctx *gin.Context) {
+	apiKey, exists := middleware.GetAPIKeyFromContext(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "API key authentication required"})
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule ID"})
+		return
+	}
+
+	var req models.JobScheduleUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schedule, err := c.jobService.UpdateSchedule(uint(id), apiKey.ClerkUserID, req)
+	if err != nil {
+		respondServiceError(ctx, err)
+		return
+	}
+
+	respondJSON(ctx, http.StatusOK, gin.H{"data": schedule})
+}
+
+// DeleteSchedule handles DELETE /public/schedules/:id
+func (c *ScheduleController) DeleteSchedule(ctx *gin.Context) {
+	apiKey, exists := middleware.GetAPIKeyFromContext(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "API key authentication required"})
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule ID"})
+		return
+	}
+
+	if err := c.jobService.DeleteSchedule(uint(id), apiKey.ClerkUserID); err != nil {
+		respondServiceError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Schedule deleted successfully"})
+}
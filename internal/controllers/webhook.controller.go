@@ -1,8 +1,10 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"ignis/internal/middleware"
 	"ignis/internal/models"
@@ -40,11 +42,14 @@ func (c *WebhookController) CreateWebhook(ctx *gin.Context) {
 
 	webhook, err := c.webhookService.CreateWebhook(req, userID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if respondIfPolicyLimit(ctx, err) {
+			return
+		}
+		respondServiceError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusCreated, gin.H{"data": webhook})
+	respondJSON(ctx, http.StatusCreated, gin.H{"data": webhook})
 }
 
 // GetWebhooks handles GET /webhooks
@@ -56,13 +61,44 @@ func (c *WebhookController) GetWebhooks(ctx *gin.Context) {
 		return
 	}
 
-	webhooks, err := c.webhookService.GetWebhooksByUser(userID)
+	// Parse pagination parameters
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 50
+	}
+
+	offset, err := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	opts := models.WebhookListOptions{
+		EventType: models.WebhookEventType(ctx.Query("event_type")),
+		Limit:     limit,
+		Offset:    offset,
+	}
+
+	if isActiveParam := ctx.Query("is_active"); isActiveParam != "" {
+		isActive, err := strconv.ParseBool(isActiveParam)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid is_active value"})
+			return
+		}
+		opts.IsActive = &isActive
+	}
+
+	webhooks, total, err := c.webhookService.GetWebhooksByUser(userID, opts)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondInternalError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"data": webhooks})
+	respondPage(ctx, webhooks, total, len(webhooks), limit, offset)
+}
+
+// GetEventTypes handles GET /webhooks/event-types - the event types webhooks can subscribe to
+func (c *WebhookController) GetEventTypes(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"data": models.WebhookEventCatalog})
 }
 
 // GetWebhook handles GET /webhooks/:id
@@ -87,7 +123,7 @@ func (c *WebhookController) GetWebhook(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"data": webhook})
+	respondJSON(ctx, http.StatusOK, gin.H{"data": webhook})
 }
 
 // UpdateWebhook handles PUT/PATCH /webhooks/:id
@@ -114,11 +150,67 @@ func (c *WebhookController) UpdateWebhook(ctx *gin.Context) {
 
 	webhook, err := c.webhookService.UpdateWebhook(uint(id), userID, req)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondServiceError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"data": webhook})
+	respondJSON(ctx, http.StatusOK, gin.H{"data": webhook})
+}
+
+// VerifyWebhook handles POST /webhooks/:id/verify - challenges the endpoint to prove the user controls it
+func (c *WebhookController) VerifyWebhook(ctx *gin.Context) {
+	// Get user ID from context (Clerk authentication required)
+	userID, exists := middleware.GetUserIDFromContext(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	idParam := ctx.Param("id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+
+	webhook, err := c.webhookService.VerifyWebhook(uint(id), userID)
+	if err != nil {
+		if errors.Is(err, services.ErrWebhookVerificationFailed) {
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		respondServiceError(ctx, err)
+		return
+	}
+
+	respondJSON(ctx, http.StatusOK, gin.H{"data": webhook})
+}
+
+// RotateWebhookSecret handles POST /webhooks/:id/rotate-secret - the new secret is only returned here
+func (c *WebhookController) RotateWebhookSecret(ctx *gin.Context) {
+	// Get user ID from context (Clerk authentication required)
+	userID, exists := middleware.GetUserIDFromContext(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	idParam := ctx.Param("id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+
+	rotated, err := c.webhookService.RotateWebhookSecret(uint(id), userID)
+	if err != nil {
+		respondServiceError(ctx, err)
+		return
+	}
+
+	// Don't let proxies or browsers keep a copy of the secret
+	ctx.Header("Cache-Control", "no-store")
+	respondJSON(ctx, http.StatusOK, gin.H{"data": rotated})
 }
 
 // DeleteWebhook handles DELETE /webhooks/:id
@@ -137,9 +229,26 @@ func (c *WebhookController) DeleteWebhook(ctx *gin.Context) {
 		return
 	}
 
-	err = c.webhookService.DeleteWebhook(uint(id), userID)
+	// The confirmation body is optional, so an empty one isn't an error
+	var req models.WebhookDeleteRequest
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	req.Confirm = ctx.Query("confirm") == "true"
+
+	recentDeliveries, err := c.webhookService.DeleteWebhook(uint(id), userID, req)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if errors.Is(err, services.ErrWebhookDeleteUnconfirmed) {
+			ctx.JSON(http.StatusConflict, gin.H{
+				"error":             "Webhook delivered events recently; repeat with ?confirm=true or its url in the body to delete it",
+				"recent_deliveries": recentDeliveries,
+			})
+			return
+		}
+		respondServiceError(ctx, err)
 		return
 	}
 
@@ -176,17 +285,151 @@ func (c *WebhookController) GetWebhookEvents(ctx *gin.Context) {
 		offset = 0
 	}
 
-	events, err := c.webhookService.GetWebhookEvents(uint(id), userID, limit, offset)
+	opts := models.WebhookEventListOptions{
+		Limit:          limit,
+		Offset:         offset,
+		Sort:           ctx.DefaultQuery("sort", "created_at"),
+		Order:          ctx.DefaultQuery("order", "desc"),
+		DeadLetter:     ctx.Query("dead_letter") == "true",
+		IncludePayload: ctx.Query("include_payload") == "true",
+	}
+
+	if sinceParam := ctx.Query("since_id"); sinceParam != "" {
+		sinceID, err := strconv.ParseUint(sinceParam, 10, 32)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid since_id"})
+			return
+		}
+		cursor := uint(sinceID)
+		opts.SinceID = &cursor
+	}
+
+	events, total, err := c.webhookService.GetWebhookEvents(uint(id), userID, opts)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondServiceError(ctx, err)
+		return
+	}
+
+	if opts.SinceID != nil {
+		// Hand back the cursor for the next page; it stays put when there is nothing new
+		nextSinceID := *opts.SinceID
+		if len(events) > 0 {
+			nextSinceID = events[len(events)-1].ID
+		}
+		respondJSON(ctx, http.StatusOK, gin.H{
+			"data": events,
+			"pagination": gin.H{
+				"limit":         limit,
+				"since_id":      *opts.SinceID,
+				"next_since_id": nextSinceID,
+				"has_more":      len(events) == limit,
+			},
+		})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"data": events,
-		"pagination": gin.H{
-			"limit":  limit,
-			"offset": offset,
-		},
+	pagination := paginationEnvelope(total, len(events), limit, offset)
+	pagination["sort"] = opts.Sort
+	pagination["order"] = opts.Order
+	respondJSON(ctx, http.StatusOK, gin.H{
+		"data":       events,
+		"pagination": pagination,
 	})
 }
+
+// AcknowledgeWebhookEvent handles POST /webhooks/:id/events/:event_id/ack - stops retries of one event
+func (c *WebhookController) AcknowledgeWebhookEvent(ctx *gin.Context) {
+	// Get user ID from context (Clerk authentication required)
+	userID, exists := middleware.GetUserIDFromContext(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	idParam := ctx.Param("id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+
+	eventID, err := strconv.ParseUint(ctx.Param("event_id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	result, err := c.webhookService.AcknowledgeWebhookEvents(uint(id), userID, []uint{uint(eventID)})
+	if err != nil {
+		respondServiceError(ctx, err)
+		return
+	}
+	if len(result.Acknowledged) == 0 {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Event not found, already delivered, or being delivered right now"})
+		return
+	}
+
+	respondJSON(ctx, http.StatusOK, gin.H{"data": result})
+}
+
+// AcknowledgeWebhookEvents handles POST /webhooks/:id/events/ack - stops retries of up to 100 events
+func (c *WebhookController) AcknowledgeWebhookEvents(ctx *gin.Context) {
+	// Get user ID from context (Clerk authentication required)
+	userID, exists := middleware.GetUserIDFromContext(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	idParam := ctx.Param("id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+
+	var req models.WebhookEventAckRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := c.webhookService.AcknowledgeWebhookEvents(uint(id), userID, req.EventIDs)
+	if err != nil {
+		respondServiceError(ctx, err)
+		return
+	}
+
+	respondJSON(ctx, http.StatusOK, gin.H{"data": result})
+}
+
+// GetWebhookLatency handles GET /webhooks/:id/latency - histogram of recent successful delivery times
+func (c *WebhookController) GetWebhookLatency(ctx *gin.Context) {
+	// Get user ID from context (Clerk authentication required)
+	userID, exists := middleware.GetUserIDFromContext(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	idParam := ctx.Param("id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+
+	hours, err := strconv.Atoi(ctx.DefaultQuery("hours", "24"))
+	if err != nil || hours < 1 || hours > 168 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "hours must be between 1 and 168"})
+		return
+	}
+
+	histogram, err := c.webhookService.GetWebhookLatency(uint(id), userID, time.Now().Add(-time.Duration(hours)*time.Hour))
+	if err != nil {
+		respondServiceError(ctx, err)
+		return
+	}
+
+	respondJSON(ctx, http.StatusOK, gin.H{"data": histogram})
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+
+	"ignis/internal/config"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// IsAdmin reports whether the authenticated user is listed in ADMIN_USER_IDS
+func IsAdmin(c *gin.Context) bool {
+	userID, exists := GetUserIDFromContext(c)
+	return exists && isAdminUserID(userID)
+}
+
+// isAdminUserID reports whether userID is listed in ADMIN_USER_IDS
+func isAdminUserID(userID string) bool {
+	if userID == "" {
+		return false
+	}
+
+	for _, adminID := range config.GetEnvList("ADMIN_USER_IDS") {
+		if adminID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireAdmin only lets through users listed in ADMIN_USER_IDS; it must run after authentication
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !IsAdmin(c) {
+			userID, _ := GetUserIDFromContext(c)
+			log.WithFields(log.Fields{
+				"clerk_user_id": userID,
+				"endpoint":      c.FullPath(),
+			}).Warn("Non-admin user attempted to access admin endpoint")
+
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -55,12 +55,21 @@ func (m *APIKeyAuthMiddleware) APIKeyAuth() gin.HandlerFunc {
 			return
 		}
 
+		// Unlimited keys belong to trusted integrations and skip rate limiting, but every use is logged for audit
+		if apiKeyData.Unlimited {
+			log.WithFields(log.Fields{
+				"api_key_id":    apiKeyData.ID,
+				"clerk_user_id": apiKeyData.ClerkUserID,
+				"endpoint":      c.FullPath(),
+			}).Info("Unlimited API key used")
+		}
+
 		// Check rate limits for this API key
-		if m.rateLimiter != nil {
+		if m.rateLimiter != nil && !apiKeyData.Unlimited {
 			endpoint := c.FullPath()
 			rateLimitKey := services.GetAPIKeyRateLimitKey(strconv.Itoa(int(apiKeyData.ID)), endpoint)
 
-			allowed, err := m.rateLimiter.Allow(rateLimitKey, apiKeyData.RateLimit, time.Minute)
+			result, err := m.rateLimiter.Check(rateLimitKey, apiKeyData.RateLimit, time.Minute)
 			if err != nil {
 				log.WithError(err).Error("Rate limiter error")
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Rate limiter error"})
@@ -68,7 +77,7 @@ func (m *APIKeyAuthMiddleware) APIKeyAuth() gin.HandlerFunc {
 				return
 			}
 
-			if !allowed {
+			if !result.Allowed {
 				c.JSON(http.StatusTooManyRequests, gin.H{
 					"error": "Rate limit exceeded",
 					"rate_limit": gin.H{
@@ -79,6 +88,7 @@ func (m *APIKeyAuthMiddleware) APIKeyAuth() gin.HandlerFunc {
 				c.Abort()
 				return
 			}
+			addRateLimitWarning(c, m.rateLimiter, "X-RateLimit", apiKeyData.RateLimit, result)
 		}
 
 		// Store API key data and user ID in context
@@ -132,7 +142,7 @@ func FlexibleAuth(apiKeyMiddleware *APIKeyAuthMiddleware) gin.HandlerFunc {
 		if hasAPIKey {
 			// Use API key authentication
 			apiKeyMiddleware.APIKeyAuth()(c)
-		} else if hasClerkAuth {
+		} else if hasClerkAuth || ClerkDevMode() {
 			// Use Clerk authentication
 			RequireClerkAuth()(c)
 		} else {
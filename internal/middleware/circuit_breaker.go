@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// circuitState represents the state of a circuit breaker
+type circuitState string
+
+const (
+	circuitClosed   circuitState = "closed"
+	circuitOpen     circuitState = "open"
+	circuitHalfOpen circuitState = "half_open"
+)
+
+// circuitBreaker fails fast after repeated failures of a dependency and
+// lets traffic through again once a cooldown has elapsed
+type circuitBreaker struct {
+	name      string
+	threshold int           // consecutive failures before opening
+	cooldown  time.Duration // how long to stay open before probing again
+
+	mutex    sync.Mutex
+	state    circuitState
+	failures int
+	openedAt time.Time
+}
+
+// newCircuitBreaker creates a closed circuit breaker
+func newCircuitBreaker(name string, threshold int, cooldown time.Duration) *circuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &circuitBreaker{
+		name:      name,
+		threshold: threshold,
+		cooldown:  cooldown,
+		state:     circuitClosed,
+	}
+}
+
+// Allow reports whether a call may proceed, moving an open circuit to half-open after the cooldown
+func (b *circuitBreaker) Allow() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.state == circuitOpen {
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.transition(circuitHalfOpen)
+	}
+	return true
+}
+
+// IsOpen reports whether the circuit is currently rejecting calls
+func (b *circuitBreaker) IsOpen() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.state == circuitOpen
+}
+
+// RetryAfter returns how long until an open circuit will allow a probe
+func (b *circuitBreaker) RetryAfter() time.Duration {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.state != circuitOpen {
+		return 0
+	}
+	remaining := b.cooldown - time.Since(b.openedAt)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// RecordSuccess closes the circuit and resets the failure count
+func (b *circuitBreaker) RecordSuccess() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.failures = 0
+	if b.state != circuitClosed {
+		b.transition(circuitClosed)
+	}
+}
+
+// RecordFailure counts a failure, opening the circuit when the threshold is reached
+// or immediately if a half-open probe fails
+func (b *circuitBreaker) RecordFailure() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.failures++
+	if b.state == circuitHalfOpen || (b.state == circuitClosed && b.failures >= b.threshold) {
+		b.openedAt = time.Now()
+		b.transition(circuitOpen)
+	}
+}
+
+// transition changes state and logs it; callers must hold the mutex
+func (b *circuitBreaker) transition(to circuitState) {
+	from := b.state
+	b.state = to
+
+	entry := log.WithFields(log.Fields{
+		"circuit":  b.name,
+		"from":     from,
+		"to":       to,
+		"failures": b.failures,
+	})
+	if to == circuitOpen {
+		entry.Error("Circuit breaker opened")
+	} else {
+		entry.Warn("Circuit breaker state changed")
+	}
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
@@ -11,6 +12,12 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// Per-minute request limits applied by StandardUserRateLimit and StandardGlobalRateLimit
+const (
+	StandardUserRequestLimit   = 100
+	StandardGlobalRequestLimit = 1000
+)
+
 // RateLimitConfig represents rate limiting configuration
 type RateLimitConfig struct {
 	Limit          int           // requests per window
@@ -59,7 +66,7 @@ func (m *RateLimitMiddleware) RateLimit(config RateLimitConfig) gin.HandlerFunc
 		}
 
 		// Check rate limit
-		allowed, err := m.rateLimiter.Allow(rateLimitKey, limit, config.Window)
+		result, err := m.rateLimiter.Check(rateLimitKey, limit, config.Window)
 		if err != nil {
 			log.WithError(err).Error("Rate limiter error")
 			if !config.SkipOnError {
@@ -73,9 +80,9 @@ func (m *RateLimitMiddleware) RateLimit(config RateLimitConfig) gin.HandlerFunc
 		}
 
 		// Add rate limit headers
-		m.addRateLimitHeaders(c, config, limit, allowed)
+		m.addRateLimitHeaders(c, config, limit, result)
 
-		if !allowed {
+		if !result.Allowed {
 			c.JSON(http.StatusTooManyRequests, gin.H{
 				"error": "Rate limit exceeded",
 				"rate_limit": gin.H{
@@ -111,7 +118,7 @@ func (m *RateLimitMiddleware) GlobalRateLimit(limit int, window time.Duration) g
 		rateLimitKey := services.GetGlobalRateLimitKey(c.FullPath())
 
 		// Check rate limit
-		allowed, err := m.rateLimiter.Allow(rateLimitKey, limit, window)
+		result, err := m.rateLimiter.Check(rateLimitKey, limit, window)
 		if err != nil {
 			log.WithError(err).Error("Global rate limiter error")
 			if !config.SkipOnError {
@@ -124,9 +131,9 @@ func (m *RateLimitMiddleware) GlobalRateLimit(limit int, window time.Duration) g
 		}
 
 		// Add rate limit headers
-		m.addRateLimitHeaders(c, config, limit, allowed)
+		m.addRateLimitHeaders(c, config, limit, result)
 
-		if !allowed {
+		if !result.Allowed {
 			c.JSON(http.StatusTooManyRequests, gin.H{
 				"error": "Global rate limit exceeded",
 				"rate_limit": gin.H{
@@ -185,27 +192,32 @@ func (m *RateLimitMiddleware) getRateLimitKeyAndLimit(c *gin.Context, config Rat
 }
 
 // addRateLimitHeaders adds rate limiting headers to the response
-func (m *RateLimitMiddleware) addRateLimitHeaders(c *gin.Context, config RateLimitConfig, limit int, allowed bool) {
+func (m *RateLimitMiddleware) addRateLimitHeaders(c *gin.Context, config RateLimitConfig, limit int, result services.RateLimitResult) {
 	// Add standard rate limit headers
 	c.Header(config.HeaderPrefix+"-Limit", strconv.Itoa(limit))
 	c.Header(config.HeaderPrefix+"-Window", config.Window.String())
-
-	if allowed {
-		c.Header(config.HeaderPrefix+"-Remaining", "available") // Could implement remaining count
-	} else {
-		c.Header(config.HeaderPrefix+"-Remaining", "0")
-	}
+	c.Header(config.HeaderPrefix+"-Remaining", strconv.Itoa(result.Remaining))
 
 	// Add reset time (approximate)
-	resetTime := time.Now().Add(config.Window)
-	c.Header(config.HeaderPrefix+"-Reset", strconv.FormatInt(resetTime.Unix(), 10))
+	c.Header(config.HeaderPrefix+"-Reset", strconv.FormatInt(result.ResetAt.Unix(), 10))
+
+	addRateLimitWarning(c, m.rateLimiter, config.HeaderPrefix, limit, result)
+}
+
+// addRateLimitWarning sets <prefix>-Warning on allowed requests once the remaining count drops
+// below RATE_LIMIT_WARNING_FRACTION of the limit, so clients can slow down before being blocked
+func addRateLimitWarning(c *gin.Context, rateLimiter *services.RateLimiterService, headerPrefix string, limit int, result services.RateLimitResult) {
+	if !result.Allowed || !rateLimiter.ShouldWarn(result.Remaining, limit) {
+		return
+	}
+	c.Header(headerPrefix+"-Warning", fmt.Sprintf("%d of %d requests remaining in the current window", result.Remaining, limit))
 }
 
 // Helper functions for common rate limit configurations
 
 // StandardUserRateLimit applies standard rate limiting for user requests (100/min)
 func (m *RateLimitMiddleware) StandardUserRateLimit() gin.HandlerFunc {
-	return m.UserRateLimit(100, time.Minute)
+	return m.UserRateLimit(StandardUserRequestLimit, time.Minute)
 }
 
 // StrictUserRateLimit applies strict rate limiting for user requests (20/min)
@@ -220,5 +232,5 @@ func (m *RateLimitMiddleware) LenientUserRateLimit() gin.HandlerFunc {
 
 // StandardGlobalRateLimit applies standard global rate limiting (1000/min)
 func (m *RateLimitMiddleware) StandardGlobalRateLimit() gin.HandlerFunc {
-	return m.GlobalRateLimit(1000, time.Minute)
+	return m.GlobalRateLimit(StandardGlobalRequestLimit, time.Minute)
 }
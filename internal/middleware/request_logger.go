@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/xid"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// RequestIDHeader carries the request ID; a client-supplied value is reused so logs can be correlated end to end
+	RequestIDHeader = "X-Request-ID"
+	// RequestIDKey is the key used to store the request ID in Gin context
+	RequestIDKey = "request_id"
+)
+
+// validRequestID limits client-supplied request IDs to a safe charset and length before they reach the logs
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID assigns every request an ID, echoed back in the X-Request-ID response header
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = xid.New().String()
+		}
+
+		c.Set(RequestIDKey, requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		c.Next()
+	}
+}
+
+// GetRequestIDFromContext extracts the request ID from Gin context
+func GetRequestIDFromContext(c *gin.Context) string {
+	return c.GetString(RequestIDKey)
+}
+
+// RequestLogger writes a structured access log entry for every request once it completes.
+// Server errors are logged at error level, client errors at warn and everything else at info.
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		status := c.Writer.Status()
+		entry := log.WithFields(log.Fields{
+			"method":     c.Request.Method,
+			"path":       c.Request.URL.Path,
+			"route":      c.FullPath(),
+			"status":     status,
+			"latency_ms": time.Since(start).Milliseconds(),
+			"client_ip":  c.ClientIP(),
+			"auth_type":  GetAuthTypeFromContext(c),
+			"request_id": GetRequestIDFromContext(c),
+		})
+		if len(c.Errors) > 0 {
+			entry = entry.WithField("errors", c.Errors.String())
+		}
+
+		switch {
+		case status >= http.StatusInternalServerError:
+			entry.Error("Request completed")
+		case status >= http.StatusBadRequest:
+			entry.Warn("Request completed")
+		default:
+			entry.Info("Request completed")
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestDurationBounds are the upper bounds, in seconds, of the request latency histogram buckets
+var requestDurationBounds = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// requestMetricsMethods are the methods recorded under their own name; clients can send any
+// token as the method, so everything else shares one series
+var requestMetricsMethods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true, http.MethodPut: true,
+	http.MethodPatch: true, http.MethodDelete: true, http.MethodConnect: true, http.MethodOptions: true,
+	http.MethodTrace: true,
+}
+
+// requestSeries identifies one latency histogram: a route answered with a class of status codes
+type requestSeries struct {
+	method      string
+	route       string // Route pattern such as /api/v1/public/jobs/:job_id, so IDs don't create new series
+	statusClass string // 2xx, 4xx, ...
+}
+
+// requestHistogram accumulates request durations for one series
+type requestHistogram struct {
+	buckets []uint64 // cumulative counts per bound in requestDurationBounds
+	count   uint64
+	sum     float64
+}
+
+// requestMetrics holds the latency histograms of every route served since the process started
+var requestMetrics = struct {
+	sync.Mutex
+	series map[requestSeries]*requestHistogram
+}{series: make(map[requestSeries]*requestHistogram)}
+
+// RequestMetrics records how long every request took in a per-route latency histogram, exposed
+// on /metrics by WriteRequestMetrics
+func RequestMetrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := c.Request.Method
+		if !requestMetricsMethods[method] {
+			method = "OTHER"
+		}
+		series := requestSeries{
+			method:      method,
+			route:       route,
+			statusClass: strconv.Itoa(c.Writer.Status()/100) + "xx",
+		}
+		observeRequest(series, time.Since(start).Seconds())
+	}
+}
+
+// observeRequest adds one request duration to its series' histogram
+func observeRequest(series requestSeries, seconds float64) {
+	requestMetrics.Lock()
+	defer requestMetrics.Unlock()
+
+	histogram, ok := requestMetrics.series[series]
+	if !ok {
+		histogram = &requestHistogram{buckets: make([]uint64, len(requestDurationBounds))}
+		requestMetrics.series[series] = histogram
+	}
+
+	histogram.count++
+	histogram.sum += seconds
+	for i, bound := range requestDurationBounds {
+		if seconds <= bound {
+			histogram.buckets[i]++
+		}
+	}
+}
+
+// WriteRequestMetrics writes the request latency histograms in the Prometheus text format.
+// Percentiles are computed by the scraper, e.g. histogram_quantile(0.95, ...).
+func WriteRequestMetrics(w io.Writer) {
+	requestMetrics.Lock()
+	defer requestMetrics.Unlock()
+
+	// Sort the series so scrapes are stable and diffable
+	keys := make([]requestSeries, 0, len(requestMetrics.series))
+	for series := range requestMetrics.series {
+		keys = append(keys, series)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].route != keys[j].route {
+			return keys[i].route < keys[j].route
+		}
+		if keys[i].method != keys[j].method {
+			return keys[i].method < keys[j].method
+		}
+		return keys[i].statusClass < keys[j].statusClass
+	})
+
+	fmt.Fprint(w, "# HELP ignis_http_request_duration_seconds Time taken to serve HTTP requests, by method, route and status class.\n")
+	fmt.Fprint(w, "# TYPE ignis_http_request_duration_seconds histogram\n")
+	for _, series := range keys {
+		histogram := requestMetrics.series[series]
+		labels := fmt.Sprintf("method=%q,route=%q,status=%q", series.method, series.route, series.statusClass)
+		for i, bound := range requestDurationBounds {
+			fmt.Fprintf(w, "ignis_http_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n",
+				labels, strconv.FormatFloat(bound, 'f', -1, 64), histogram.buckets[i])
+		}
+		fmt.Fprintf(w, "ignis_http_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, histogram.count)
+		fmt.Fprintf(w, "ignis_http_request_duration_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(histogram.sum, 'f', -1, 64))
+		fmt.Fprintf(w, "ignis_http_request_duration_seconds_count{%s} %d\n", labels, histogram.count)
+	}
+}
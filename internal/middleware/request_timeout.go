@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestTimeoutHeader lets clients bound how long the server spends on their request
+const RequestTimeoutHeader = "X-Request-Timeout"
+
+// minRequestTimeout is the smallest deadline a client may ask for
+const minRequestTimeout = 100 * time.Millisecond
+
+// RequestTimeout applies the client-supplied X-Request-Timeout (seconds or a Go
+// duration such as "1500ms") to the request context, capped at maxTimeout.
+// Requests without the header are left untouched.
+func RequestTimeout(maxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw := c.GetHeader(RequestTimeoutHeader)
+		if raw == "" {
+			c.Next()
+			return
+		}
+
+		timeout, err := parseRequestTimeout(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+		if timeout < minRequestTimeout {
+			timeout = minRequestTimeout
+		}
+		if timeout > maxTimeout {
+			timeout = maxTimeout
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		// Handlers that bail out on the cancelled context may not have responded
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			c.JSON(http.StatusGatewayTimeout, gin.H{"error": "Request timed out"})
+		}
+	}
+}
+
+// parseRequestTimeout accepts a number of seconds (fractions allowed) or a Go duration string
+func parseRequestTimeout(raw string) (time.Duration, error) {
+	if seconds, err := strconv.ParseFloat(raw, 64); err == nil && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+	if duration, err := time.ParseDuration(raw); err == nil && duration > 0 {
+		return duration, nil
+	}
+	return 0, fmt.Errorf("invalid %s header: expected seconds or a duration like 1500ms", RequestTimeoutHeader)
+}
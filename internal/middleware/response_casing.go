@@ -0,0 +1,171 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ResponseCasingParam selects the casing of JSON response keys, as a query parameter or as a
+// parameter of the Accept header (Accept: application/json; casing=camel)
+const ResponseCasingParam = "casing"
+
+// userDataKeys hold caller-supplied objects whose keys are returned exactly as submitted
+var userDataKeys = map[string]bool{
+	"metadata": true,
+}
+
+// ResponseCasing rewrites the keys of JSON responses from snake_case to camelCase for clients
+// that ask for casing=camel. Other responses, and requests that don't ask, are left untouched.
+func ResponseCasing() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if requestedCasing(c) != "camel" {
+			c.Next()
+			return
+		}
+
+		writer := &casingResponseWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+		writer.finish()
+	}
+}
+
+// requestedCasing returns the casing asked for by the query parameter, falling back to the Accept header
+func requestedCasing(c *gin.Context) string {
+	if casing := c.Query(ResponseCasingParam); casing != "" {
+		return strings.ToLower(casing)
+	}
+	for _, accept := range strings.Split(c.GetHeader("Accept"), ",") {
+		if _, params, err := mime.ParseMediaType(strings.TrimSpace(accept)); err == nil {
+			if casing := params[ResponseCasingParam]; casing != "" {
+				return strings.ToLower(casing)
+			}
+		}
+	}
+	return ""
+}
+
+// casingResponseWriter holds back JSON bodies so their keys can be rewritten once the handler
+// is done. Anything else, such as CSV exports and artifact downloads, streams through as written.
+type casingResponseWriter struct {
+	gin.ResponseWriter
+	status      int
+	passthrough bool
+	buffered    bool
+	body        bytes.Buffer
+}
+
+func (w *casingResponseWriter) WriteHeader(code int) {
+	if w.passthrough {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.status = code
+}
+
+func (w *casingResponseWriter) WriteHeaderNow() {
+	if w.passthrough {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *casingResponseWriter) Write(data []byte) (int, error) {
+	if !w.buffered && !w.passthrough && !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		w.passthrough = true
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	if w.passthrough {
+		return w.ResponseWriter.Write(data)
+	}
+	w.buffered = true
+	return w.body.Write(data)
+}
+
+func (w *casingResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *casingResponseWriter) Status() int {
+	if w.passthrough {
+		return w.ResponseWriter.Status()
+	}
+	return w.status
+}
+
+func (w *casingResponseWriter) Written() bool {
+	return w.buffered || w.ResponseWriter.Written()
+}
+
+// finish writes the held-back JSON body with its keys converted, or as-is if it isn't valid JSON
+func (w *casingResponseWriter) finish() {
+	if w.passthrough {
+		return
+	}
+	if !w.buffered {
+		// Bodiless responses such as 204 still need their status passed on
+		w.ResponseWriter.WriteHeader(w.status)
+		return
+	}
+
+	body := w.body.Bytes()
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err == nil {
+		if converted, err := json.Marshal(camelCaseKeys(value)); err == nil {
+			body = converted
+		}
+	}
+
+	w.ResponseWriter.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.Write(body)
+}
+
+// camelCaseKeys converts the object keys of a decoded JSON value, recursively, leaving the
+// contents of userDataKeys as they are
+func camelCaseKeys(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			if userDataKeys[key] {
+				converted[snakeToCamel(key)] = child
+				continue
+			}
+			converted[snakeToCamel(key)] = camelCaseKeys(child)
+		}
+		return converted
+	case []interface{}:
+		for i, child := range v {
+			v[i] = camelCaseKeys(child)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// snakeToCamel converts a snake_case key such as job_id to camelCase (jobId)
+func snakeToCamel(key string) string {
+	if !strings.Contains(key, "_") {
+		return key
+	}
+
+	parts := strings.Split(key, "_")
+	var b strings.Builder
+	b.WriteString(parts[0])
+	for _, part := range parts[1:] {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/clerk/clerk-sdk-go/v2"
+)
+
+// sessionCacheSweepSize is the entry count above which expired entries are swept on insert
+const sessionCacheSweepSize = 1000
+
+// sessionCacheEntry holds verified claims until they expire
+type sessionCacheEntry struct {
+	claims    *clerk.SessionClaims
+	expiresAt time.Time
+}
+
+// sessionCache keeps recently verified Clerk session tokens so chatty clients
+// skip re-verification. Entries never outlive the token's own expiry.
+type sessionCache struct {
+	ttl     time.Duration
+	mutex   sync.RWMutex
+	entries map[string]sessionCacheEntry
+}
+
+// newSessionCache creates a session cache; a non-positive ttl disables caching
+func newSessionCache(ttl time.Duration) *sessionCache {
+	return &sessionCache{
+		ttl:     ttl,
+		entries: make(map[string]sessionCacheEntry),
+	}
+}
+
+// Get returns cached claims for a token if they are still valid
+func (s *sessionCache) Get(token string) (*clerk.SessionClaims, bool) {
+	if s.ttl <= 0 || token == "" {
+		return nil, false
+	}
+
+	key := sessionCacheKey(token)
+	s.mutex.RLock()
+	entry, exists := s.entries[key]
+	s.mutex.RUnlock()
+
+	if !exists {
+		return nil, false
+	}
+	if !time.Now().Before(entry.expiresAt) {
+		s.mutex.Lock()
+		delete(s.entries, key)
+		s.mutex.Unlock()
+		return nil, false
+	}
+	return entry.claims, true
+}
+
+// Set caches verified claims until the earlier of the cache TTL and the token expiry
+func (s *sessionCache) Set(token string, claims *clerk.SessionClaims) {
+	if s.ttl <= 0 || token == "" || claims == nil || claims.Expiry == nil {
+		return
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.ttl)
+	if tokenExpiry := time.Unix(*claims.Expiry, 0); tokenExpiry.Before(expiresAt) {
+		expiresAt = tokenExpiry
+	}
+	if !now.Before(expiresAt) {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(s.entries) >= sessionCacheSweepSize {
+		for key, entry := range s.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(s.entries, key)
+			}
+		}
+	}
+	s.entries[sessionCacheKey(token)] = sessionCacheEntry{claims: claims, expiresAt: expiresAt}
+}
+
+// sessionCacheKey hashes the token so raw credentials are not used as map keys
+func sessionCacheKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+	_ "time/tzdata" // Embed zone data so X-Timezone works in minimal containers
+
+	"github.com/gin-gonic/gin"
+)
+
+// TimezoneHeader selects the timezone timestamps are rendered in
+const TimezoneHeader = "X-Timezone"
+
+// timezoneKey is the key used to store the requested location in Gin context
+const timezoneKey = "timezone"
+
+// Timezone resolves the display timezone from the X-Timezone header or the tz
+// query parameter (an IANA name such as "Europe/Berlin"). Defaults to UTC.
+func Timezone() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.GetHeader(TimezoneHeader)
+		if name == "" {
+			name = c.Query("tz")
+		}
+
+		if name != "" {
+			loc, err := time.LoadLocation(name)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid timezone: " + name})
+				c.Abort()
+				return
+			}
+			c.Set(timezoneKey, loc)
+		}
+
+		c.Next()
+	}
+}
+
+// GetTimezoneFromContext returns the requested display location, or UTC
+func GetTimezoneFromContext(c *gin.Context) *time.Location {
+	if loc, exists := c.Get(timezoneKey); exists {
+		if location, ok := loc.(*time.Location); ok {
+			return location
+		}
+	}
+	return time.UTC
+}
@@ -3,11 +3,21 @@ package models
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+const (
+	// APIKeyPrefix is prepended to every generated API key
+	APIKeyPrefix = "ign_"
+	// apiKeyRandomBytes is the number of random bytes hex-encoded into a key
+	apiKeyRandomBytes = 32
+	// APIKeyLength is the total length of a generated API key
+	APIKeyLength = len(APIKeyPrefix) + apiKeyRandomBytes*2
+)
+
 // APIKey represents an API key for authentication
 type APIKey struct {
 	ID          uint           `json:"id" gorm:"primaryKey"`
@@ -16,7 +26,10 @@ type APIKey struct {
 	KeyPrefix   string         `json:"key_prefix" gorm:"not null;size:16"`     // First 8 chars for identification
 	ClerkUserID string         `json:"clerk_user_id" gorm:"not null;size:100;index"`
 	IsActive    bool           `json:"is_active" gorm:"default:true"`
-	RateLimit   int            `json:"rate_limit" gorm:"default:100"` // requests per minute
+	RateLimit   int            `json:"rate_limit" gorm:"default:100"`  // requests per minute
+	Unlimited   bool           `json:"unlimited" gorm:"default:false"` // Skips per-key rate limiting; only admins can create these
+	Metadata    APIKeyMetadata `json:"metadata,omitempty" gorm:"type:json"`
+	Tier        UserTier       `json:"tier,omitempty" gorm:"type:varchar(20)"` // Sets the default and highest job priority; only admins set it
 	LastUsedAt  *time.Time     `json:"last_used_at,omitempty"`
 	ExpiresAt   *time.Time     `json:"expires_at,omitempty"`
 	CreatedAt   time.Time      `json:"created_at"`
@@ -29,24 +42,74 @@ func (APIKey) TableName() string {
 	return "api_keys"
 }
 
+// apiKeyTierPriorities are the priority jobs submitted with a key of each tier get when they
+// don't ask for one, and the highest they may ask for
+var apiKeyTierPriorities = map[UserTier]struct{ Default, Max JobPriority }{
+	UserTierFree:       {Default: JobPriorityNormal, Max: JobPriorityNormal},
+	UserTierPro:        {Default: JobPriorityNormal, Max: JobPriorityHigh},
+	UserTierEnterprise: {Default: JobPriorityHigh, Max: JobPriorityHigh},
+}
+
+// APIKeyMetadata is a set of caller-defined labels on an API key (environment, team, purpose),
+// stored as a JSON object like job metadata
+type APIKeyMetadata = JobMetadata
+
 // APIKeyCreateRequest represents the request to create an API key
 type APIKeyCreateRequest struct {
-	Name      string     `json:"name" binding:"required,min=1,max=100"`
-	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Name      string         `json:"name" binding:"required,min=1,max=100"`
+	ExpiresAt *time.Time     `json:"expires_at,omitempty"`
+	Unlimited bool           `json:"unlimited,omitempty"` // Admin only
+	Metadata  APIKeyMetadata `json:"metadata,omitempty" binding:"max=20,dive,keys,min=1,max=50,endkeys,max=500"`
+}
+
+// APIKeyUpdateRequest represents the request to update an API key; omitted fields are left unchanged
+type APIKeyUpdateRequest struct {
+	IsActive *bool          `json:"is_active,omitempty"`
+	Metadata APIKeyMetadata `json:"metadata,omitempty" binding:"max=20,dive,keys,min=1,max=50,endkeys,max=500"` // Replaces the existing metadata; {} clears it
+}
+
+// APIKeyTierUpdateRequest sets the tier of an API key; an empty tier removes it
+type APIKeyTierUpdateRequest struct {
+	Tier string `json:"tier" binding:"omitempty,oneof=free pro enterprise"`
+}
+
+// APIKeyBulkUpdateRequest represents the request to enable or disable several API keys at once
+type APIKeyBulkUpdateRequest struct {
+	IDs      []uint `json:"ids" binding:"required,min=1,max=100"`
+	IsActive *bool  `json:"is_active" binding:"required"`
+}
+
+// APIKeyBulkUpdateResult reports the outcome of a bulk API key update
+type APIKeyBulkUpdateResult struct {
+	Updated  int64  `json:"updated"`   // Keys whose status actually changed
+	NotFound []uint `json:"not_found"` // Requested IDs that don't exist or belong to another user
 }
 
 // APIKeyResponse represents the API key response (without sensitive data)
 type APIKeyResponse struct {
-	ID          uint       `json:"id"`
-	Name        string     `json:"name"`
-	KeyPrefix   string     `json:"key_prefix"`
-	ClerkUserID string     `json:"clerk_user_id"`
-	IsActive    bool       `json:"is_active"`
-	RateLimit   int        `json:"rate_limit"`
-	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
-	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
+	ID          uint           `json:"id"`
+	Name        string         `json:"name"`
+	KeyPrefix   string         `json:"key_prefix"`
+	ClerkUserID string         `json:"clerk_user_id"`
+	IsActive    bool           `json:"is_active"`
+	RateLimit   int            `json:"rate_limit"`
+	Unlimited   bool           `json:"unlimited"`
+	Metadata    APIKeyMetadata `json:"metadata,omitempty"`
+	Tier        UserTier       `json:"tier,omitempty"`
+	LastUsedAt  *time.Time     `json:"last_used_at,omitempty"`
+	ExpiresAt   *time.Time     `json:"expires_at,omitempty"`
+	Expired     bool           `json:"expired"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// APIKeyListOptions controls filtering and pagination of API key listings
+type APIKeyListOptions struct {
+	IsActive *bool
+	Expired  *bool             // Compared against the current time; keys without an expiry never expire
+	Metadata map[string]string // Keys must carry every one of these metadata entries
+	Limit    int
+	Offset   int
 }
 
 // APIKeyCreateResponse includes the raw key for initial response only
@@ -57,11 +120,20 @@ type APIKeyCreateResponse struct {
 
 // GenerateAPIKey generates a new API key string
 func GenerateAPIKey() (string, error) {
-	bytes := make([]byte, 32)
+	bytes := make([]byte, apiKeyRandomBytes)
 	if _, err := rand.Read(bytes); err != nil {
 		return "", err
 	}
-	return "ign_" + hex.EncodeToString(bytes), nil
+	return APIKeyPrefix + hex.EncodeToString(bytes), nil
+}
+
+// IsWellFormedAPIKey checks that a raw key has the prefix, length and encoding of a generated key
+func IsWellFormedAPIKey(rawKey string) bool {
+	if len(rawKey) != APIKeyLength || !strings.HasPrefix(rawKey, APIKeyPrefix) {
+		return false
+	}
+	_, err := hex.DecodeString(strings.TrimPrefix(rawKey, APIKeyPrefix))
+	return err == nil
 }
 
 // IsExpired checks if the API key is expired
@@ -72,6 +144,23 @@ func (a *APIKey) IsExpired() bool {
 	return time.Now().After(*a.ExpiresAt)
 }
 
+// JobPriority returns the priority a job submitted with this key runs at: the requested one, or
+// the tier's default when none was requested, capped at the tier's maximum. Keys without a tier
+// are treated as free, the most restrictive tier.
+func (a *APIKey) JobPriority(requested JobPriority) JobPriority {
+	priorities, ok := apiKeyTierPriorities[a.Tier]
+	if !ok {
+		priorities = apiKeyTierPriorities[UserTierFree]
+	}
+	if requested == "" {
+		return priorities.Default
+	}
+	if requested.rank() > priorities.Max.rank() {
+		return priorities.Max
+	}
+	return requested
+}
+
 // CanUse checks if the API key can be used (active and not expired)
 func (a *APIKey) CanUse() bool {
 	return a.IsActive && !a.IsExpired()
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"bytes"
+	"compress/gzip"
+	"database/sql/driver"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// compressedTextPrefix marks a stored value as gzip-compressed and base64-encoded. Text columns
+// can't hold raw gzip bytes, and the prefix lets compressed and plain rows coexist.
+const compressedTextPrefix = "gzip+b64:"
+
+// compressedTextMinSize is the smallest value worth compressing
+const compressedTextMinSize = 512
+
+// textCompressionEnabled controls whether CompressedText values are compressed when written
+var textCompressionEnabled bool
+
+// SetTextCompression turns compression of CompressedText columns on or off for future writes.
+// Values are always decompressed on read, so it can be toggled at any time.
+func SetTextCompression(enabled bool) {
+	textCompressionEnabled = enabled
+}
+
+// CompressedText is a text column that is transparently gzip-compressed when compression is enabled
+type CompressedText string
+
+// Value implements the driver.Valuer interface for database storage
+func (t CompressedText) Value() (driver.Value, error) {
+	value := string(t)
+	// Plain values that happen to start with the prefix are always compressed so they read back intact
+	if !strings.HasPrefix(value, compressedTextPrefix) &&
+		(!textCompressionEnabled || len(value) < compressedTextMinSize) {
+		return value, nil
+	}
+
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write([]byte(value)); err != nil {
+		return nil, fmt.Errorf("failed to compress text: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress text: %w", err)
+	}
+	return compressedTextPrefix + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// Scan implements the sql.Scanner interface for database retrieval
+func (t *CompressedText) Scan(value interface{}) error {
+	var stored string
+	switch v := value.(type) {
+	case nil:
+		*t = ""
+		return nil
+	case []byte:
+		stored = string(v)
+	case string:
+		stored = v
+	default:
+		return fmt.Errorf("cannot scan %T into CompressedText", value)
+	}
+
+	if !strings.HasPrefix(stored, compressedTextPrefix) {
+		*t = CompressedText(stored)
+		return nil
+	}
+
+	compressed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(stored, compressedTextPrefix))
+	if err != nil {
+		return fmt.Errorf("failed to decode compressed text: %w", err)
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return fmt.Errorf("failed to decompress text: %w", err)
+	}
+	defer reader.Close()
+
+	plain, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("failed to decompress text: %w", err)
+	}
+	*t = CompressedText(plain)
+	return nil
+}
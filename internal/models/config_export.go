@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// ConfigExportVersion is the format version written to config exports
+const ConfigExportVersion = 1
+
+// ConfigExport is a portable copy of a user's webhooks and API keys for backups or moving between
+// accounts. Webhook secrets and raw API keys are never included; importing generates new ones.
+type ConfigExport struct {
+	Version    int                   `json:"version"`
+	ExportedAt time.Time             `json:"exported_at"`
+	Webhooks   []WebhookConfigExport `json:"webhooks" binding:"max=100,dive"`
+	APIKeys    []APIKeyConfigExport  `json:"api_keys" binding:"max=100,dive"`
+}
+
+// WebhookConfigExport is the exported configuration of one webhook
+type WebhookConfigExport struct {
+	URL         string             `json:"url" binding:"required,url,max=500"`
+	Events      WebhookEventTypes  `json:"events"`
+	IsActive    bool               `json:"is_active"`
+	RateLimit   int                `json:"rate_limit,omitempty" binding:"min=0,max=6000"`
+	Canonical   bool               `json:"canonical_json,omitempty"`
+	Format      WebhookFormat      `json:"format,omitempty" binding:"omitempty,oneof=json form"`
+	RetryPolicy WebhookRetryPolicy `json:"retry_policy,omitempty" binding:"omitempty,oneof=exponential linear fixed"`
+	RetryDelay  int                `json:"retry_base_delay,omitempty" binding:"min=0,max=3600"`
+	MaxAttempts int                `json:"max_attempts,omitempty" binding:"min=0,max=20"`
+}
+
+// APIKeyConfigExport is the exported configuration of one API key
+type APIKeyConfigExport struct {
+	Name      string         `json:"name" binding:"required,min=1,max=100"`
+	IsActive  bool           `json:"is_active"`
+	Metadata  APIKeyMetadata `json:"metadata,omitempty" binding:"max=20,dive,keys,min=1,max=50,endkeys,max=500"`
+	ExpiresAt *time.Time     `json:"expires_at,omitempty"`
+}
+
+// ConfigImportResult reports what an import created, in the order of the imported document.
+// New webhook secrets and API keys are only returned here.
+type ConfigImportResult struct {
+	Webhooks []WebhookImportResult `json:"webhooks"`
+	APIKeys  []APIKeyImportResult  `json:"api_keys"`
+}
+
+// WebhookImportResult is the outcome of importing one webhook
+type WebhookImportResult struct {
+	URL     string           `json:"url"`
+	Webhook *WebhookResponse `json:"webhook,omitempty"`
+	Secret  string           `json:"secret,omitempty"`
+	Skipped string           `json:"skipped,omitempty"` // Why the webhook wasn't created
+	SkipErr error            `json:"-"`                 // Error that skipped it, for the handler to report
+}
+
+// APIKeyImportResult is the outcome of importing one API key. Keys whose name is already taken
+// are created under a numbered name such as "ci (2)".
+type APIKeyImportResult struct {
+	Name    string                `json:"name"`
+	APIKey  *APIKeyCreateResponse `json:"api_key,omitempty"`
+	Skipped string                `json:"skipped,omitempty"` // Why the key wasn't created
+	SkipErr error                 `json:"-"`                 // Error that skipped it, for the handler to report
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"time"
 
 	"gorm.io/gorm"
@@ -14,25 +16,114 @@ const (
 	JobStatusRunning   JobStatus = "running"
 	JobStatusCompleted JobStatus = "completed"
 	JobStatusFailed    JobStatus = "failed"
+	JobStatusScheduled JobStatus = "scheduled" // Waiting for run_at before being sent to the workers
+	JobStatusCancelled JobStatus = "cancelled" // Cancelled by its owner before it finished
 )
 
+// IsTerminal reports whether a job in this status has finished and won't change again
+func (s JobStatus) IsTerminal() bool {
+	return s == JobStatusCompleted || s == JobStatusFailed || s == JobStatusCancelled
+}
+
+// CodeHash returns the hex SHA-256 of submitted code, so jobs that ran identical code can be
+// recognized without comparing the code itself
+func CodeHash(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// JobPriority is how urgently a job should be picked up by the workers
+type JobPriority string
+
+const (
+	JobPriorityLow    JobPriority = "low"
+	JobPriorityNormal JobPriority = "normal"
+	JobPriorityHigh   JobPriority = "high"
+)
+
+// rank orders priorities from low (0) to high (2); unknown priorities rank as normal
+func (p JobPriority) rank() int {
+	switch p {
+	case JobPriorityLow:
+		return 0
+	case JobPriorityHigh:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// Raised returns the next higher priority, or the same priority if it is already the highest
+func (p JobPriority) Raised() JobPriority {
+	switch p {
+	case JobPriorityLow:
+		return JobPriorityNormal
+	case JobPriorityNormal:
+		return JobPriorityHigh
+	default:
+		return JobPriorityHigh
+	}
+}
+
+// Subject returns the NATS subject jobs of this priority are published to. Normal priority
+// uses the original "jobs" subject so existing workers keep receiving them.
+func (p JobPriority) Subject() string {
+	switch p {
+	case JobPriorityLow:
+		return "jobs.low"
+	case JobPriorityHigh:
+		return "jobs.high"
+	default:
+		return "jobs"
+	}
+}
+
 // Job represents a job in the system
 type Job struct {
-	ID           uint           `json:"id" gorm:"primaryKey"`
-	JobID        string         `json:"job_id" gorm:"uniqueIndex;not null;size:50"`
-	Language     string         `json:"language" gorm:"not null;size:50"`
-	Code         string         `json:"code" gorm:"type:text;not null"`
-	Status       JobStatus      `json:"status" gorm:"type:varchar(20);default:'received'"`
-	Message      string         `json:"message,omitempty" gorm:"type:text"`
-	Error        string         `json:"error,omitempty" gorm:"type:text"`
-	StdErr       string         `json:"stderr,omitempty" gorm:"type:text"`
-	StdOut       string         `json:"stdout,omitempty" gorm:"type:text"`
-	ExecDuration int            `json:"exec_duration,omitempty"`
-	MemUsage     int64          `json:"mem_usage,omitempty"`
-	ClerkUserID  string         `json:"clerk_user_id" gorm:"not null;size:100;index"`
-	CreatedAt    time.Time      `json:"created_at"`
-	UpdatedAt    time.Time      `json:"updated_at"`
-	DeletedAt    gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+	ID                uint           `json:"id" gorm:"primaryKey"`
+	JobID             string         `json:"job_id" gorm:"uniqueIndex;not null;size:50"`
+	Language          string         `json:"language" gorm:"not null;size:50"`
+	Name              string         `json:"name,omitempty" gorm:"size:100"`
+	Description       string         `json:"description,omitempty" gorm:"size:500"`
+	Tags              JobTags        `json:"tags,omitempty" gorm:"type:json"`
+	Metadata          JobMetadata    `json:"metadata,omitempty" gorm:"type:json"`
+	Args              JobArgs        `json:"args,omitempty" gorm:"type:json"`         // Passed to the program on its command line
+	WorkerVersion     string         `json:"worker_version,omitempty" gorm:"size:50"` // Only workers of this version run the job
+	Code              CompressedText `json:"code" gorm:"type:text;not null"`
+	CodeHash          string         `json:"code_hash,omitempty" gorm:"size:64;index"` // Hex SHA-256 of the code; kept when the code is purged
+	Status            JobStatus      `json:"status" gorm:"type:varchar(20);default:'received'"`
+	Priority          JobPriority    `json:"priority" gorm:"type:varchar(10);default:'normal'"`           // Priority requested at submission
+	EffectivePriority JobPriority    `json:"effective_priority" gorm:"type:varchar(10);default:'normal'"` // Raised by aging while the job waits
+	AgedAt            *time.Time     `json:"aged_at,omitempty"`                                           // When the effective priority was last raised
+	Message           string         `json:"message,omitempty" gorm:"type:text"`
+	Error             string         `json:"error,omitempty" gorm:"type:text"`
+	StdErr            CompressedText `json:"stderr,omitempty" gorm:"type:text"`
+	StdOut            CompressedText `json:"stdout,omitempty" gorm:"type:text"`
+	StdErrRef         string         `json:"-" gorm:"size:200"` // Output store key when stderr was offloaded
+	StdOutRef         string         `json:"-" gorm:"size:200"` // Output store key when stdout was offloaded
+	ExecDuration      int            `json:"exec_duration,omitempty"`
+	CompileDuration   int            `json:"compile_duration,omitempty"` // Part of exec_duration spent compiling, for compiled languages
+	RunDuration       int            `json:"run_duration,omitempty"`     // Part of exec_duration spent running the program
+	MemUsage          int64          `json:"mem_usage,omitempty"`
+	StartedAt         *time.Time     `json:"started_at,omitempty"`                // Wall-clock start of execution, as reported by the worker
+	FinishedAt        *time.Time     `json:"finished_at,omitempty"`               // Wall-clock end of execution, as reported by the worker
+	WorkerID          string         `json:"worker_id,omitempty" gorm:"size:100"` // Worker that reported the job's latest status
+	Region            string         `json:"region,omitempty" gorm:"size:50"`     // Region of that worker
+	ForcedBy          string         `json:"-" gorm:"size:100"`                   // Admin who forced the job's final status; later worker updates are ignored
+	ClerkUserID       string         `json:"clerk_user_id" gorm:"not null;size:100;index"`
+	APIKeyID          *uint          `json:"api_key_id,omitempty" gorm:"index"`            // Key the job was submitted with, if any
+	DeadlineAt        *time.Time     `json:"deadline_at,omitempty" gorm:"index"`           // Job fails if not started by then
+	RunAt             *time.Time     `json:"run_at,omitempty" gorm:"index"`                // Scheduled jobs are sent to the workers at this time
+	DispatchedAt      *time.Time     `json:"dispatched_at,omitempty"`                      // When NATS confirmed receipt of the job
+	PublishedSubject  string         `json:"published_subject,omitempty" gorm:"size:100"`  // NATS subject the job was last published to
+	CodePurgedAt      *time.Time     `json:"code_purged_at,omitempty"`                     // Code was cleared by JOB_CODE_RETENTION
+	OutputPurgedAt    *time.Time     `json:"output_purged_at,omitempty"`                   // Output was cleared by JOB_OUTPUT_RETENTION
+	ImportedAt        *time.Time     `json:"imported_at,omitempty"`                        // Set on historical jobs brought in through POST /jobs/import
+	Ephemeral         bool           `json:"ephemeral,omitempty" gorm:"default:false"`     // Code and output are cleared as soon as the job finishes
+	ParentJobID       string         `json:"parent_job_id,omitempty" gorm:"size:50;index"` // Job this one re-runs, e.g. through revalidation
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
 }
 
 // TableName sets the table name for the Job model
@@ -42,48 +133,239 @@ func (Job) TableName() string {
 
 // JobCreateRequest represents the request to create a job
 type JobCreateRequest struct {
-	Language string `json:"language" binding:"required,min=1,max=50"`
-	Code     string `json:"code" binding:"required,min=1"`
+	Language      string      `json:"language" binding:"required,min=1,max=50"`
+	Code          string      `json:"code" binding:"required,min=1"`
+	Name          string      `json:"name,omitempty" binding:"max=100"`                                           // Friendly label, e.g. "nightly regression #42"
+	Description   string      `json:"description,omitempty" binding:"max=500"`                                    // Free-form notes about the job
+	Tags          JobTags     `json:"tags,omitempty" binding:"max=20,dive,min=1,max=50"`                          // Labels for grouping jobs
+	Metadata      JobMetadata `json:"metadata,omitempty" binding:"max=20,dive,keys,min=1,max=50,endkeys,max=500"` // Returned as-is, e.g. in webhooks
+	Args          JobArgs     `json:"args,omitempty" binding:"max=50,dive,max=1024"`                              // Command-line arguments for the program
+	WorkerVersion string      `json:"worker_version,omitempty" binding:"max=50"`                                  // Pin to a worker version advertised by the workers
+	APIKeyID      *uint       `json:"-"`                                                                          // Set by the server for submissions authenticated with an API key
+	Ephemeral     bool        `json:"ephemeral,omitempty"`                                                        // Clear the code and output once the job finishes and its webhooks are queued
+	ParentJobID   string      `json:"-"`                                                                          // Set by the server when re-running an earlier job
+	Internal      bool        `json:"-"`                                                                          // Submitted by the server itself (schedules, the canary); skips the resubmission cooldown
+	Deadline      *time.Time  `json:"deadline,omitempty"`                                                         // RFC3339; the job fails if it hasn't started by then
+	RunAt         *time.Time  `json:"run_at,omitempty"`                                                           // RFC3339; the job is held as scheduled until then
+	Priority      JobPriority `json:"priority,omitempty" binding:"omitempty,oneof=low normal high"`               // Defaults to normal
+}
+
+// JobImportRecord is a finished job from another platform, imported as-is without being run
+type JobImportRecord struct {
+	Language     string      `json:"language" binding:"required,min=1,max=50"`
+	Code         string      `json:"code" binding:"required,min=1"`
+	Name         string      `json:"name,omitempty" binding:"max=100"`
+	Description  string      `json:"description,omitempty" binding:"max=500"`
+	Tags         JobTags     `json:"tags,omitempty" binding:"max=20,dive,min=1,max=50"`
+	Metadata     JobMetadata `json:"metadata,omitempty" binding:"max=20,dive,keys,min=1,max=50,endkeys,max=500"`
+	Status       JobStatus   `json:"status" binding:"required,oneof=completed failed"`
+	Message      string      `json:"message,omitempty"`
+	Error        string      `json:"error,omitempty"`
+	StdErr       string      `json:"stderr,omitempty"`
+	StdOut       string      `json:"stdout,omitempty"`
+	ExecDuration int         `json:"exec_duration,omitempty" binding:"min=0"`
+	MemUsage     int64       `json:"mem_usage,omitempty" binding:"min=0"`
+	CreatedAt    time.Time   `json:"created_at" binding:"required"`
+	UpdatedAt    *time.Time  `json:"updated_at,omitempty"` // Defaults to created_at
+}
+
+// JobImportRequest represents the request to import historical jobs
+type JobImportRequest struct {
+	Jobs []JobImportRecord `json:"jobs" binding:"required,min=1,max=500,dive"`
+}
+
+// JobListFilter narrows job listings and exports
+type JobListFilter struct {
+	Status      JobStatus
+	Language    string
+	Search      string // Case-insensitive match against name and description
+	APIKeyID    *uint  // Only jobs submitted with this API key
+	ParentJobID string // Only re-runs of this job, such as its revalidations
+}
+
+// JobStatusCursor is where a batch status poll resumes: after the job last returned, ordered by
+// update time then job ID so jobs sharing an update time aren't skipped
+type JobStatusCursor struct {
+	UpdatedAt time.Time
+	JobID     string // Empty resumes after every job updated at UpdatedAt
+}
+
+// AdminJobResponse is the admin view of a job, with the routing details used for debugging
+type AdminJobResponse struct {
+	JobResponse
+	ExpectedSubject string `json:"expected_subject"`    // Subject the job's current priority and worker version route to
+	ForcedBy        string `json:"forced_by,omitempty"` // Admin who forced the job's final status
+}
+
+// JobForceStatusRequest is an operator's resolution of a job wedged by a lost worker
+type JobForceStatusRequest struct {
+	Status  JobStatus `json:"status" binding:"required,oneof=completed failed"`
+	Message string    `json:"message" binding:"max=1000"` // Stored as the job's error when failing it
 }
 
 // JobResponse represents the job response
 type JobResponse struct {
-	ID           uint      `json:"id"`
-	JobID        string    `json:"job_id"`
-	Language     string    `json:"language"`
-	Code         string    `json:"code"`
-	Status       JobStatus `json:"status"`
-	Message      string    `json:"message,omitempty"`
-	Error        string    `json:"error,omitempty"`
-	StdErr       string    `json:"stderr,omitempty"`
-	StdOut       string    `json:"stdout,omitempty"`
-	ExecDuration int       `json:"exec_duration,omitempty"`
-	MemUsage     int64     `json:"mem_usage,omitempty"`
-	ClerkUserID  string    `json:"clerk_user_id"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID                    uint        `json:"id"`
+	JobID                 string      `json:"job_id"`
+	Language              string      `json:"language"`
+	Name                  string      `json:"name,omitempty"`
+	Description           string      `json:"description,omitempty"`
+	Tags                  JobTags     `json:"tags,omitempty"`
+	Metadata              JobMetadata `json:"metadata,omitempty"`
+	Args                  JobArgs     `json:"args,omitempty"`
+	WorkerVersion         string      `json:"worker_version,omitempty"`
+	Code                  string      `json:"code"`
+	CodeHash              string      `json:"code_hash,omitempty"`
+	Status                JobStatus   `json:"status"`
+	Priority              JobPriority `json:"priority"`
+	EffectivePriority     JobPriority `json:"effective_priority"`
+	Message               string      `json:"message,omitempty"`
+	Error                 string      `json:"error,omitempty"`
+	StdErr                string      `json:"stderr,omitempty"`
+	StdOut                string      `json:"stdout,omitempty"`
+	OutputStored          bool        `json:"output_stored,omitempty"` // Listings only: stdout/stderr are in the output store, fetch the job to read them
+	ExecDuration          int         `json:"exec_duration,omitempty"`
+	CompileDuration       int         `json:"compile_duration,omitempty"`
+	RunDuration           int         `json:"run_duration,omitempty"`
+	MemUsage              int64       `json:"mem_usage,omitempty"`
+	StartedAt             *time.Time  `json:"started_at,omitempty"`
+	FinishedAt            *time.Time  `json:"finished_at,omitempty"`
+	WorkerID              string      `json:"worker_id,omitempty"`
+	Region                string      `json:"region,omitempty"`
+	ClerkUserID           string      `json:"clerk_user_id"`
+	APIKeyID              *uint       `json:"api_key_id,omitempty"`
+	DeadlineAt            *time.Time  `json:"deadline_at,omitempty"`
+	RunAt                 *time.Time  `json:"run_at,omitempty"`
+	Dispatched            bool        `json:"dispatched"` // NATS confirmed it received the job
+	DispatchedAt          *time.Time  `json:"dispatched_at,omitempty"`
+	PublishedSubject      string      `json:"published_subject,omitempty"`
+	CodePurgedAt          *time.Time  `json:"code_purged_at,omitempty"`
+	OutputPurgedAt        *time.Time  `json:"output_purged_at,omitempty"`
+	Ephemeral             bool        `json:"ephemeral,omitempty"`
+	ParentJobID           string      `json:"parent_job_id,omitempty"`
+	ImportedAt            *time.Time  `json:"imported_at,omitempty"`
+	Warning               string      `json:"warning,omitempty"`                 // Set on submission, e.g. for a language outside the registry
+	QueuePosition         int64       `json:"queue_position,omitempty"`          // 1 for the next job a worker picks up; received jobs only
+	EstimatedCompletionAt *time.Time  `json:"estimated_completion_at,omitempty"` // Received and running jobs only, from recent completions
+	CreatedAt             time.Time   `json:"created_at"`
+	UpdatedAt             time.Time   `json:"updated_at"`
+}
+
+// JobDeliveryIssue summarizes a job whose webhook notifications haven't been delivered
+type JobDeliveryIssue struct {
+	JobID             string    `json:"job_id"`
+	Language          string    `json:"language"`
+	Status            JobStatus `json:"status"`
+	ClerkUserID       string    `json:"clerk_user_id"`
+	UndeliveredEvents int64     `json:"undelivered_events"`
+	LastAttemptAt     time.Time `json:"last_attempt_at"`
+	CreatedAt         time.Time `json:"created_at"`
 }
 
 type JobWebhookResponse struct {
-	JobID        string    `json:"job_id"`
-	Language     string    `json:"language"`
-	Code         string    `json:"code"`
-	Status       JobStatus `json:"status"`
-	Message      string    `json:"message,omitempty"`
-	Error        string    `json:"error,omitempty"`
-	StdErr       string    `json:"stderr,omitempty"`
-	StdOut       string    `json:"stdout,omitempty"`
-	ExecDuration int       `json:"exec_duration,omitempty"`
-	MemUsage     int64     `json:"mem_usage,omitempty"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	JobID           string      `json:"job_id"`
+	Language        string      `json:"language"`
+	Name            string      `json:"name,omitempty"`
+	Description     string      `json:"description,omitempty"`
+	Tags            JobTags     `json:"tags,omitempty"`
+	Metadata        JobMetadata `json:"metadata,omitempty"`
+	Code            string      `json:"code"`
+	Status          JobStatus   `json:"status"`
+	PreviousStatus  JobStatus   `json:"previous_status,omitempty"` // Only set for job.status_changed events
+	Message         string      `json:"message,omitempty"`
+	Error           string      `json:"error,omitempty"`
+	StdErr          string      `json:"stderr,omitempty"`
+	StdOut          string      `json:"stdout,omitempty"`
+	ExecDuration    int         `json:"exec_duration,omitempty"`
+	CompileDuration int         `json:"compile_duration,omitempty"`
+	RunDuration     int         `json:"run_duration,omitempty"`
+	MemUsage        int64       `json:"mem_usage,omitempty"`
+	StartedAt       *time.Time  `json:"started_at,omitempty"`
+	FinishedAt      *time.Time  `json:"finished_at,omitempty"`
+	Ephemeral       bool        `json:"ephemeral,omitempty"` // The job's code and output are not kept after this delivery
+	CreatedAt       time.Time   `json:"created_at"`
+	UpdatedAt       time.Time   `json:"updated_at"`
+}
+
+// JobRevalidation compares a finished job with a fresh run of the same code on the current workers
+type JobRevalidation struct {
+	OriginalJobID  string      `json:"original_job_id"`
+	OriginalStatus JobStatus   `json:"original_status"`
+	NewStatus      JobStatus   `json:"new_status,omitempty"`      // Empty while the rerun hasn't finished
+	OutcomeChanged *bool       `json:"outcome_changed,omitempty"` // Unset while the rerun hasn't finished
+	Job            JobResponse `json:"job"`                       // The rerun, with parent_job_id pointing at the original
+}
+
+// JobInFlightCounts is a point-in-time count of jobs that have not finished yet
+type JobInFlightCounts struct {
+	Received   int64     `json:"received"`
+	Running    int64     `json:"running"`
+	ComputedAt time.Time `json:"computed_at"`
+}
+
+// JobStatsOverview is the system-wide throughput of finished jobs over a recent window. It holds
+// aggregates only, never code, user identifiers or per-user counts.
+type JobStatsOverview struct {
+	WindowHours     int       `json:"window_hours"`
+	Completed       int64     `json:"completed"`
+	Failed          int64     `json:"failed"`
+	FailureRate     float64   `json:"failure_rate"`      // Failed share of finished jobs, 0 when none finished
+	AvgExecDuration float64   `json:"avg_exec_duration"` // Mean exec_duration of completed jobs
+	ComputedAt      time.Time `json:"computed_at"`
+}
+
+// CanaryResult reports the outcome of an end-to-end canary job
+type CanaryResult struct {
+	Success     bool      `json:"success"`
+	JobID       string    `json:"job_id,omitempty"`
+	Status      JobStatus `json:"status,omitempty"`
+	RoundTripMs int64     `json:"round_trip_ms"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// LanguageUsage is the anonymized number of jobs submitted in a language
+type LanguageUsage struct {
+	Language string `json:"language"`
+	Count    int64  `json:"count"`
+}
+
+// JobCostEstimate represents the projected cost and resource allotment of a submission
+type JobCostEstimate struct {
+	Language       string  `json:"language"`
+	CodeSizeBytes  int     `json:"code_size_bytes"`
+	EstimatedCost  float64 `json:"estimated_cost"`
+	Currency       string  `json:"currency"`
+	TimeoutSeconds int     `json:"timeout_seconds"`
+	MemoryLimitMB  int     `json:"memory_limit_mb"`
 }
 
 // BenchJob represents the job structure expected by the worker
 type BenchJob struct {
-	ID       string `json:"id"`
+	ID            string      `json:"id"`
+	Language      string      `json:"language"`
+	Code          string      `json:"code"`
+	Args          []string    `json:"args,omitempty"`           // Command-line arguments to run the program with
+	WorkerVersion string      `json:"worker_version,omitempty"` // Set on pinned jobs, which go to a version-specific subject
+	DeadlineAt    *time.Time  `json:"deadline_at,omitempty"`    // Workers should skip the job if it can't start by then
+	Priority      JobPriority `json:"priority,omitempty"`       // Effective priority; aged jobs are re-published at a higher one
+}
+
+// WarmupHint is published alongside a job so workers can prepare the language runtime early
+type WarmupHint struct {
+	JobID    string `json:"job_id"`
 	Language string `json:"language"`
-	Code     string `json:"code"`
+}
+
+// WorkerHeartbeat is published periodically by each worker on "workers.heartbeat" to advertise
+// the version it runs, so jobs can be pinned to it
+type WorkerHeartbeat struct {
+	WorkerID string `json:"worker_id"`
+	Version  string `json:"version"`
+}
+
+// JobCancelSignal is published to the worker running a job to stop it
+type JobCancelSignal struct {
+	JobID string `json:"job_id"`
 }
 
 // JobStatusUpdate represents job status updates from the worker
@@ -95,5 +377,15 @@ type JobStatusUpdate struct {
 	StdErr       string `json:"stderr"`
 	StdOut       string `json:"stdout"`
 	ExecDuration int    `json:"exec_duration"`
-	MemUsage     int64  `json:"mem_usage"`
+	// Breakdown of exec_duration reported by workers for compiled languages
+	CompileDuration int    `json:"compile_duration,omitempty"`
+	RunDuration     int    `json:"run_duration,omitempty"`
+	MemUsage        int64  `json:"mem_usage"`
+	WorkerID        string `json:"worker_id,omitempty"` // Reported by workers that identify themselves
+	// Wall-clock execution bounds; workers send started_at once running and finished_at when done
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Region     string     `json:"region,omitempty"`
+
+	Artifacts []JobArtifactReport `json:"artifacts,omitempty"` // Files the job produced, already uploaded to the output store
 }
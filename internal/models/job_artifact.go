@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+)
+
+// JobArtifact is a file a job produced. Workers upload the file to the output store and report
+// its key in their status update; the content is served back through the API.
+type JobArtifact struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	JobID       string    `json:"job_id" gorm:"not null;size:50;uniqueIndex:idx_job_artifacts_job_filename"`
+	Filename    string    `json:"filename" gorm:"not null;size:255;uniqueIndex:idx_job_artifacts_job_filename"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"content_type,omitempty" gorm:"size:100"`
+	StorageRef  string    `json:"-" gorm:"not null;size:500"` // Output store key of the content
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName sets the table name for the JobArtifact model
+func (JobArtifact) TableName() string {
+	return "job_artifacts"
+}
+
+// JobArtifactReport is how a worker reports an artifact in a JobStatusUpdate
+type JobArtifactReport struct {
+	Filename    string `json:"filename"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type,omitempty"`
+	Ref         string `json:"ref"` // Output store key, under jobs/<job_id>/artifacts/
+}
+
+// JobArtifactResponse represents the job artifact response
+type JobArtifactResponse struct {
+	ID          uint      `json:"id"`
+	JobID       string    `json:"job_id"`
+	Filename    string    `json:"filename"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"content_type,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
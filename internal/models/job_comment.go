@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// JobComment represents a note left on a job by a user
+type JobComment struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	JobID       string         `json:"job_id" gorm:"not null;size:50;index"`
+	ClerkUserID string         `json:"clerk_user_id" gorm:"not null;size:100;index"` // Author of the comment
+	Text        string         `json:"text" gorm:"type:text;not null"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+}
+
+// TableName sets the table name for the JobComment model
+func (JobComment) TableName() string {
+	return "job_comments"
+}
+
+// JobCommentCreateRequest represents the request to comment on a job
+type JobCommentCreateRequest struct {
+	Text string `json:"text" binding:"required,min=1,max=2000"`
+}
+
+// JobCommentResponse represents the job comment response
+type JobCommentResponse struct {
+	ID          uint      `json:"id"`
+	JobID       string    `json:"job_id"`
+	ClerkUserID string    `json:"clerk_user_id"`
+	Text        string    `json:"text"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// JobTags is a list of free-form labels used to group jobs, stored as a JSON array
+type JobTags []string
+
+// Value implements the driver.Valuer interface for database storage
+func (t JobTags) Value() (driver.Value, error) {
+	if t == nil {
+		return nil, nil
+	}
+	return json.Marshal(t)
+}
+
+// Scan implements the sql.Scanner interface for database retrieval
+func (t *JobTags) Scan(value interface{}) error {
+	bytes, err := jsonColumnBytes(value)
+	if err != nil || bytes == nil {
+		*t = nil
+		return err
+	}
+	return json.Unmarshal(bytes, t)
+}
+
+// JobArgs are the command-line arguments a job's program is run with, stored as a JSON array
+type JobArgs []string
+
+// Value implements the driver.Valuer interface for database storage
+func (a JobArgs) Value() (driver.Value, error) {
+	if a == nil {
+		return nil, nil
+	}
+	return json.Marshal(a)
+}
+
+// Scan implements the sql.Scanner interface for database retrieval
+func (a *JobArgs) Scan(value interface{}) error {
+	bytes, err := jsonColumnBytes(value)
+	if err != nil || bytes == nil {
+		*a = nil
+		return err
+	}
+	return json.Unmarshal(bytes, a)
+}
+
+// JobMetadata is a set of caller-defined key/value pairs attached to a job, stored as a JSON object
+type JobMetadata map[string]string
+
+// Value implements the driver.Valuer interface for database storage
+func (m JobMetadata) Value() (driver.Value, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return json.Marshal(m)
+}
+
+// Scan implements the sql.Scanner interface for database retrieval
+func (m *JobMetadata) Scan(value interface{}) error {
+	bytes, err := jsonColumnBytes(value)
+	if err != nil || bytes == nil {
+		*m = nil
+		return err
+	}
+	return json.Unmarshal(bytes, m)
+}
+
+// jsonColumnBytes returns the raw bytes of a JSON column value, or nil for NULL
+func jsonColumnBytes(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	default:
+		return nil, fmt.Errorf("cannot scan %T into a JSON column", value)
+	}
+}
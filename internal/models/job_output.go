@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// JobOutput holds a large job output moved off the jobs table by the database output store
+type JobOutput struct {
+	Key       string `gorm:"primaryKey;size:200"`
+	Data      string `gorm:"type:text;not null"`
+	CreatedAt time.Time
+}
+
+// TableName sets the table name for the JobOutput model
+func (JobOutput) TableName() string {
+	return "job_outputs"
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// JobSchedule is a recurring job: a new job is created from it each time its cron expression fires
+type JobSchedule struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	Name        string         `json:"name,omitempty" gorm:"size:100"`
+	Cron        string         `json:"cron" gorm:"not null;size:100"` // Five-field cron expression, evaluated in UTC
+	Language    string         `json:"language" gorm:"not null;size:50"`
+	Code        string         `json:"code" gorm:"type:text;not null"`
+	IsActive    bool           `json:"is_active" gorm:"default:true"`
+	ClerkUserID string         `json:"clerk_user_id" gorm:"not null;size:100;index"`
+	LastRunAt   *time.Time     `json:"last_run_at,omitempty"`
+	LastJobID   string         `json:"last_job_id,omitempty" gorm:"size:50"`
+	NextRunAt   *time.Time     `json:"next_run_at,omitempty" gorm:"index"` // nil while the schedule is paused
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+}
+
+// TableName sets the table name for the JobSchedule model
+func (JobSchedule) TableName() string {
+	return "job_schedules"
+}
+
+// JobScheduleCreateRequest represents the request to create a recurring job schedule
+type JobScheduleCreateRequest struct {
+	Name     string `json:"name,omitempty" binding:"max=100"`
+	Cron     string `json:"cron" binding:"required,max=100"` // e.g. "0 3 * * *" for 03:00 UTC every day
+	Language string `json:"language" binding:"required,min=1,max=50"`
+	Code     string `json:"code" binding:"required,min=1"`
+}
+
+// JobScheduleUpdateRequest represents the request to update a recurring job schedule
+type JobScheduleUpdateRequest struct {
+	Name     string `json:"name,omitempty" binding:"max=100"`
+	Cron     string `json:"cron,omitempty" binding:"max=100"`
+	Language string `json:"language,omitempty" binding:"max=50"`
+	Code     string `json:"code,omitempty"`
+	IsActive *bool  `json:"is_active,omitempty"`
+}
+
+// JobScheduleResponse represents the recurring job schedule response
+type JobScheduleResponse struct {
+	ID          uint       `json:"id"`
+	Name        string     `json:"name,omitempty"`
+	Cron        string     `json:"cron"`
+	Language    string     `json:"language"`
+	Code        string     `json:"code"`
+	IsActive    bool       `json:"is_active"`
+	ClerkUserID string     `json:"clerk_user_id"`
+	LastRunAt   *time.Time `json:"last_run_at,omitempty"`
+	LastJobID   string     `json:"last_job_id,omitempty"`
+	NextRunAt   *time.Time `json:"next_run_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
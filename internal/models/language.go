@@ -0,0 +1,75 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Language describes an execution language supported by the workers
+type Language struct {
+	Name    string   `json:"name"`    // Canonical name stored on jobs and sent to workers
+	Aliases []string `json:"aliases"` // Alternative spellings accepted on submission
+
+	// MaxOutputBytes caps how much stdout and stderr a job in this language keeps;
+	// 0 uses the global JOB_OUTPUT_MAX_BYTES
+	MaxOutputBytes int `json:"max_output_bytes,omitempty"`
+}
+
+// SupportedLanguages is the registry of languages jobs can be submitted in
+var SupportedLanguages = []Language{
+	{Name: "python", Aliases: []string{"py", "python3"}, MaxOutputBytes: 4 * 1024 * 1024},
+	{Name: "go", Aliases: []string{"golang"}},
+}
+
+// LanguageNames returns the canonical names of all supported languages
+func LanguageNames() []string {
+	names := make([]string, 0, len(SupportedLanguages))
+	for _, language := range SupportedLanguages {
+		names = append(names, language.Name)
+	}
+	return names
+}
+
+// LanguageRegistryFingerprint identifies the current contents of SupportedLanguages, so cached
+// responses built from the registry can tell when it changed
+func LanguageRegistryFingerprint() string {
+	var b strings.Builder
+	for _, language := range SupportedLanguages {
+		b.WriteString(language.Name)
+		for _, alias := range language.Aliases {
+			b.WriteByte(',')
+			b.WriteString(alias)
+		}
+		fmt.Fprintf(&b, ":%d", language.MaxOutputBytes)
+		b.WriteByte(';')
+	}
+	return b.String()
+}
+
+// LanguageMaxOutputBytes returns the output cap configured for a canonical language name, or 0
+// when the language has none or isn't in the registry
+func LanguageMaxOutputBytes(name string) int {
+	for _, language := range SupportedLanguages {
+		if language.Name == name {
+			return language.MaxOutputBytes
+		}
+	}
+	return 0
+}
+
+// CanonicalLanguage resolves a submitted language name or alias, in any casing, to its
+// canonical name, returning an error for languages not in the registry
+func CanonicalLanguage(raw string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(raw))
+	for _, language := range SupportedLanguages {
+		if normalized == language.Name {
+			return language.Name, nil
+		}
+		for _, alias := range language.Aliases {
+			if normalized == alias {
+				return language.Name, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("unsupported language %q, supported languages: %s", strings.TrimSpace(raw), strings.Join(LanguageNames(), ", "))
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"reflect"
+	"time"
+)
+
+// TimestampLayout is the format used for every timestamp returned by the API
+const TimestampLayout = time.RFC3339
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// FormatTimestamp renders t as RFC3339 in the given location (UTC when nil)
+func FormatTimestamp(t time.Time, loc *time.Location) string {
+	return NormalizeTimestamp(t, loc).Format(TimestampLayout)
+}
+
+// NormalizeTimestamp converts t to the given location (UTC when nil) at second
+// precision, so it serializes identically to FormatTimestamp
+func NormalizeTimestamp(t time.Time, loc *time.Location) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	return t.In(loc).Truncate(time.Second)
+}
+
+// LocalizeTimestamps normalizes every time.Time reachable from v (structs, pointers,
+// slices and maps such as gin.H) in place, so responses share one timestamp format
+func LocalizeTimestamps(v interface{}, loc *time.Location) {
+	if v == nil {
+		return
+	}
+	localizeValue(reflect.ValueOf(v), loc)
+}
+
+// localizeValue returns v with its timestamps normalized; values that can be
+// updated in place are, others are returned as a modified copy
+func localizeValue(v reflect.Value, loc *time.Location) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() && v.Elem().CanSet() {
+			v.Elem().Set(localizeValue(v.Elem(), loc))
+		}
+	case reflect.Interface:
+		if !v.IsNil() {
+			return localizeValue(v.Elem(), loc)
+		}
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return reflect.ValueOf(NormalizeTimestamp(v.Interface().(time.Time), loc))
+		}
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(v)
+		for i := 0; i < copied.NumField(); i++ {
+			if field := copied.Field(i); field.CanSet() {
+				field.Set(localizeValue(field, loc))
+			}
+		}
+		return copied
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return v
+		}
+		if v.Kind() == reflect.Array && !v.CanAddr() {
+			copied := reflect.New(v.Type()).Elem()
+			copied.Set(v)
+			v = copied
+		}
+		for i := 0; i < v.Len(); i++ {
+			v.Index(i).Set(localizeValue(v.Index(i), loc))
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			v.SetMapIndex(key, localizeValue(v.MapIndex(key), loc))
+		}
+	}
+	return v
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// UserTier is a user's plan, which sets their default rate limit and resource quotas
+type UserTier string
+
+const (
+	UserTierFree       UserTier = "free"
+	UserTierPro        UserTier = "pro"
+	UserTierEnterprise UserTier = "enterprise"
+)
+
+// UserTiers lists every tier, lowest first
+var UserTiers = []UserTier{UserTierFree, UserTierPro, UserTierEnterprise}
+
+// ParseUserTier validates a tier name
+func ParseUserTier(value string) (UserTier, error) {
+	for _, tier := range UserTiers {
+		if string(tier) == value {
+			return tier, nil
+		}
+	}
+	return "", fmt.Errorf("unknown tier %q", value)
+}
+
+// UserQuota records the tier assigned to a user; users without a row get the default tier
+type UserQuota struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	ClerkUserID string    `json:"clerk_user_id" gorm:"uniqueIndex;not null;size:100"`
+	Tier        UserTier  `json:"tier" gorm:"type:varchar(20);not null"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName sets the table name for the UserQuota model
+func (UserQuota) TableName() string {
+	return "user_quotas"
+}
+
+// TierLimits are the limits that apply to users on a tier. Zero means unlimited.
+type TierLimits struct {
+	APIKeyRateLimit     int `json:"api_key_rate_limit"`    // Requests per minute given to new API keys
+	MaxAPIKeys          int `json:"max_api_keys"`          // Active API keys a user may hold
+	MaxWebhooks         int `json:"max_webhooks"`          // Webhooks a user may register
+	DailyComputeMinutes int `json:"daily_compute_minutes"` // Total job execution time per UTC day
+	MaxCodeBytes        int `json:"max_code_bytes"`        // Largest code submission accepted
+}
+
+// AccountUsage is how much of their tier's limits a user currently uses
+type AccountUsage struct {
+	APIKeys             int64   `json:"api_keys"`
+	Webhooks            int64   `json:"webhooks"`
+	ComputeMinutesToday float64 `json:"compute_minutes_today"`
+}
+
+// AccountResponse describes a user's tier, its limits and their usage
+type AccountResponse struct {
+	ClerkUserID string       `json:"clerk_user_id"`
+	Tier        UserTier     `json:"tier"`
+	Limits      TierLimits   `json:"limits"`
+	Usage       AccountUsage `json:"usage"`
+}
+
+// AccountLimitsResponse is the effective limits for the caller, whether authenticated as a user
+// or with an API key, and how much of each is in use
+type AccountLimitsResponse struct {
+	Tier                UserTier           `json:"tier"`
+	RateLimit           int                `json:"rate_limit"` // Requests per window; 0 when unlimited
+	RateLimitWindow     string             `json:"rate_limit_window"`
+	Unlimited           bool               `json:"unlimited"`
+	DailyComputeMinutes int                `json:"daily_compute_minutes"`
+	MaxCodeBytes        int                `json:"max_code_bytes"`
+	Usage               AccountLimitsUsage `json:"usage"`
+}
+
+// AccountLimitsUsage is the caller's current consumption of their limits
+type AccountLimitsUsage struct {
+	JobSubmissions      *int    `json:"job_submissions,omitempty"` // Job submissions in the current window; API keys only
+	PendingJobs         int64   `json:"pending_jobs"`
+	ComputeMinutesToday float64 `json:"compute_minutes_today"`
+}
+
+// UserTierUpdateRequest represents an operator's request to change a user's tier
+type UserTierUpdateRequest struct {
+	Tier string `json:"tier" binding:"required,oneof=free pro enterprise"`
+}
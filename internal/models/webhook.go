@@ -13,8 +13,60 @@ import (
 type WebhookEventType string
 
 const (
-	WebhookEventJobCompleted WebhookEventType = "job.completed"
-	WebhookEventJobFailed    WebhookEventType = "job.failed"
+	WebhookEventJobCompleted     WebhookEventType = "job.completed"
+	WebhookEventJobFailed        WebhookEventType = "job.failed"
+	WebhookEventJobCancelled     WebhookEventType = "job.cancelled"
+	WebhookEventJobStatusChanged WebhookEventType = "job.status_changed" // Every transition; job.status is the new status
+)
+
+// WebhookEventTypeInfo describes an event type webhooks can subscribe to
+type WebhookEventTypeInfo struct {
+	Type        WebhookEventType `json:"type"`
+	Description string           `json:"description"`
+}
+
+// WebhookEventCatalog lists every event type the service emits. Subscriptions are validated
+// against it, so a new event type must be added here before it can be sent.
+var WebhookEventCatalog = []WebhookEventTypeInfo{
+	{Type: WebhookEventJobCompleted, Description: "A job finished running successfully"},
+	{Type: WebhookEventJobFailed, Description: "A job failed, timed out, or missed its deadline"},
+	{Type: WebhookEventJobCancelled, Description: "A job was cancelled before it finished, whether scheduled, queued or running"},
+	{Type: WebhookEventJobStatusChanged, Description: "A job moved to any other status; the payload carries the new status and previous_status"},
+}
+
+// IsKnown reports whether the event type is listed in WebhookEventCatalog
+func (t WebhookEventType) IsKnown() bool {
+	for _, info := range WebhookEventCatalog {
+		if info.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookFormat is how a webhook's payloads are encoded
+type WebhookFormat string
+
+const (
+	WebhookFormatJSON WebhookFormat = "json" // application/json (default)
+	WebhookFormatForm WebhookFormat = "form" // application/x-www-form-urlencoded with flattened keys such as job.job_id
+)
+
+// ContentType returns the Content-Type header sent with payloads in this format
+func (f WebhookFormat) ContentType() string {
+	if f == WebhookFormatForm {
+		return "application/x-www-form-urlencoded"
+	}
+	return "application/json"
+}
+
+// WebhookRetryPolicy is how the delay between a webhook's failed delivery attempts grows
+type WebhookRetryPolicy string
+
+const (
+	WebhookRetryExponential WebhookRetryPolicy = "exponential" // base delay doubled after every attempt
+	WebhookRetryLinear      WebhookRetryPolicy = "linear"      // base delay times the attempt number
+	WebhookRetryFixed       WebhookRetryPolicy = "fixed"       // base delay between every attempt
 )
 
 // WebhookEventTypes is a custom type for handling JSON serialization of event types slice
@@ -50,15 +102,28 @@ func (w *WebhookEventTypes) Scan(value interface{}) error {
 
 // Webhook represents a webhook configuration
 type Webhook struct {
-	ID          uint              `json:"id" gorm:"primaryKey"`
-	URL         string            `json:"url" gorm:"not null;size:500"`
-	Secret      string            `json:"-" gorm:"size:100"` // HMAC secret for signature verification
-	Events      WebhookEventTypes `json:"events" gorm:"type:json;not null"`
-	IsActive    bool              `json:"is_active" gorm:"default:true"`
-	ClerkUserID string            `json:"clerk_user_id" gorm:"not null;size:100;index"`
-	CreatedAt   time.Time         `json:"created_at"`
-	UpdatedAt   time.Time         `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt    `json:"deleted_at,omitempty" gorm:"index"`
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	URL    string `json:"url" gorm:"not null;size:500"`
+	Secret string `json:"-" gorm:"size:100"` // HMAC secret for signature verification
+
+	PreviousSecret          string     `json:"-" gorm:"size:100"` // Secret before the last rotation, still signed with until PreviousSecretExpiresAt
+	PreviousSecretExpiresAt *time.Time `json:"-"`
+
+	Events      WebhookEventTypes  `json:"events" gorm:"type:json;not null"`
+	IsActive    bool               `json:"is_active" gorm:"default:true"`
+	RateLimit   int                `json:"rate_limit" gorm:"default:0"`           // Deliveries per minute; 0 uses WEBHOOK_DELIVERY_RATE_LIMIT
+	Canonical   bool               `json:"canonical_json" gorm:"default:false"`   // Send payloads as canonical JSON (sorted keys, no whitespace)
+	Format      WebhookFormat      `json:"format" gorm:"size:10;default:json"`    // How payloads are encoded
+	RetryPolicy WebhookRetryPolicy `json:"retry_policy,omitempty" gorm:"size:20"` // Empty uses the default schedule: quick retries, then hourly
+	RetryDelay  int                `json:"retry_base_delay,omitempty"`            // Base delay in seconds for RetryPolicy; 0 uses WEBHOOK_RETRY_BASE_DELAY
+	MaxAttempts int                `json:"max_attempts,omitempty"`                // 0 uses WEBHOOK_MAX_ATTEMPTS
+	Verified    bool               `json:"verified" gorm:"default:false"`         // The endpoint echoed a verification challenge
+	VerifiedAt  *time.Time         `json:"verified_at,omitempty"`
+	Reverify    bool               `json:"-" gorm:"default:false"` // Deactivated by a URL change; reactivated once the new URL is verified
+	ClerkUserID string             `json:"clerk_user_id" gorm:"not null;size:100;index"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt     `json:"deleted_at,omitempty" gorm:"index"`
 }
 
 // TableName sets the table name for the Webhook model
@@ -74,11 +139,18 @@ type WebhookEvent struct {
 	EventType    WebhookEventType `json:"event_type" gorm:"not null;size:50"`
 	JobID        string           `json:"job_id" gorm:"not null;size:50;index"`
 	Payload      string           `json:"payload" gorm:"type:text;not null"`
+	Format       WebhookFormat    `json:"format,omitempty" gorm:"size:10"` // Encoding of Payload; empty for events queued before formats existed (JSON)
 	Delivered    bool             `json:"delivered" gorm:"default:false"`
 	StatusCode   int              `json:"status_code,omitempty"`
 	Response     string           `json:"response,omitempty" gorm:"type:text"`
 	AttemptCount int              `json:"attempt_count" gorm:"default:0"`
-	NextRetryAt  *time.Time       `json:"next_retry_at,omitempty"`
+	DurationMs   int64            `json:"duration_ms,omitempty"`                // How long the latest attempt took
+	NextRetryAt  *time.Time       `json:"next_retry_at,omitempty" gorm:"index"` // When the next delivery attempt is due; nil once finished
+	ClaimedUntil *time.Time       `json:"-"`                                    // Reserves the event for a delivery worker
+	DedupedInto  *uint            `json:"deduped_into,omitempty"`               // Event that delivered this one to the same URL and secret
+	Ephemeral    bool             `json:"-" gorm:"default:false"`               // Payload is for an ephemeral job and is cleared once the event is finished
+	AckedAt      *time.Time       `json:"acknowledged_at,omitempty"`            // The receiver acknowledged the event through the API instead of a 2xx response
+	DeadLetterAt *time.Time       `json:"dead_letter_at,omitempty"`             // Delivery was given up on: retries ran out, the webhook went away or the payload couldn't be built
 	CreatedAt    time.Time        `json:"created_at"`
 	UpdatedAt    time.Time        `json:"updated_at"`
 }
@@ -88,30 +160,97 @@ func (WebhookEvent) TableName() string {
 	return "webhook_events"
 }
 
+// WebhookEventAttempt records the outcome of one delivery attempt. It is written as soon as the
+// receiver answers, so a successful attempt survives a crash before the event itself is updated.
+type WebhookEventAttempt struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	EventID    uint      `json:"event_id" gorm:"not null;index"`
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"status_code,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	Error      string    `json:"error,omitempty" gorm:"size:500"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// TableName sets the table name for the WebhookEventAttempt model
+func (WebhookEventAttempt) TableName() string {
+	return "webhook_event_attempts"
+}
+
 // WebhookCreateRequest represents the request to create a webhook
 type WebhookCreateRequest struct {
-	URL    string            `json:"url" binding:"required,url,max=500"`
-	Secret string            `json:"secret,omitempty" binding:"max=100"`
-	Events WebhookEventTypes `json:"events" binding:"required,min=1"`
+	URL       string            `json:"url" binding:"required,url,max=500"`
+	Secret    string            `json:"secret,omitempty" binding:"max=100"`
+	Events    WebhookEventTypes `json:"events,omitempty"` // Falls back to WEBHOOK_DEFAULT_EVENTS when empty, if configured
+	RateLimit int               `json:"rate_limit,omitempty" binding:"min=0,max=6000"`
+	Canonical bool              `json:"canonical_json,omitempty"` // Sign and send sorted-key JSON for receivers that re-serialize
+	Format    WebhookFormat     `json:"format,omitempty" binding:"omitempty,oneof=json form"`
+	// Retry cadence for failed deliveries; retry_base_delay is in seconds
+	RetryPolicy WebhookRetryPolicy `json:"retry_policy,omitempty" binding:"omitempty,oneof=exponential linear fixed"`
+	RetryDelay  int                `json:"retry_base_delay,omitempty" binding:"min=0,max=3600"`
+	MaxAttempts int                `json:"max_attempts,omitempty" binding:"min=0,max=20"`
 }
 
-// WebhookUpdateRequest represents the request to update a webhook
+// WebhookUpdateRequest represents the request to update a webhook. Omitted fields are left
+// alone; fields sent with an empty value are cleared, e.g. "secret": "" stops signing and
+// "rate_limit": 0 goes back to WEBHOOK_DELIVERY_RATE_LIMIT.
 type WebhookUpdateRequest struct {
-	URL      string            `json:"url,omitempty" binding:"omitempty,url,max=500"`
-	Secret   string            `json:"secret,omitempty" binding:"max=100"`
-	Events   WebhookEventTypes `json:"events,omitempty" binding:"omitempty,min=1"`
-	IsActive *bool             `json:"is_active,omitempty"`
+	URL       *string            `json:"url,omitempty" binding:"omitempty,url,max=500"`
+	Secret    *string            `json:"secret,omitempty" binding:"omitempty,max=100"`
+	Events    *WebhookEventTypes `json:"events,omitempty"` // An empty list unsubscribes from everything
+	IsActive  *bool              `json:"is_active,omitempty"`
+	RateLimit *int               `json:"rate_limit,omitempty" binding:"omitempty,min=0,max=6000"`
+	Canonical *bool              `json:"canonical_json,omitempty"`
+	Format    *WebhookFormat     `json:"format,omitempty" binding:"omitempty,oneof='' json form"`
+	// Retry cadence for failed deliveries; retry_base_delay is in seconds
+	RetryPolicy *WebhookRetryPolicy `json:"retry_policy,omitempty" binding:"omitempty,oneof='' exponential linear fixed"`
+	RetryDelay  *int                `json:"retry_base_delay,omitempty" binding:"omitempty,min=0,max=3600"`
+	MaxAttempts *int                `json:"max_attempts,omitempty" binding:"omitempty,min=0,max=20"`
+}
+
+// WebhookDeleteRequest confirms deleting a webhook that delivered events recently, either with
+// ?confirm=true or by repeating the webhook's URL in the body
+type WebhookDeleteRequest struct {
+	Confirm bool   `form:"confirm" json:"-"`
+	URL     string `json:"url,omitempty"`
+}
+
+// WebhookEventAckRequest lists the events a receiver has processed out of band
+type WebhookEventAckRequest struct {
+	EventIDs []uint `json:"event_ids" binding:"required,min=1,max=100"`
+}
+
+// WebhookEventAckResult reports which events were acknowledged. Events that don't belong to the
+// webhook, were already delivered, or are being delivered at that moment are not.
+type WebhookEventAckResult struct {
+	Acknowledged    []uint `json:"acknowledged"`
+	NotAcknowledged []uint `json:"not_acknowledged"`
+}
+
+// WebhookSecretRotateResponse carries a webhook's new secret, only returned by the rotation
+type WebhookSecretRotateResponse struct {
+	WebhookResponse
+	Secret                  string     `json:"secret"`
+	PreviousSecretExpiresAt *time.Time `json:"previous_secret_expires_at,omitempty"` // Until then deliveries are also signed with the old secret
 }
 
 // WebhookResponse represents the webhook response
 type WebhookResponse struct {
-	ID          uint              `json:"id"`
-	URL         string            `json:"url"`
-	Events      WebhookEventTypes `json:"events"`
-	IsActive    bool              `json:"is_active"`
-	ClerkUserID string            `json:"clerk_user_id"`
-	CreatedAt   time.Time         `json:"created_at"`
-	UpdatedAt   time.Time         `json:"updated_at"`
+	ID          uint               `json:"id"`
+	URL         string             `json:"url"`
+	Events      WebhookEventTypes  `json:"events"`
+	IsActive    bool               `json:"is_active"`
+	RateLimit   int                `json:"rate_limit"`
+	Canonical   bool               `json:"canonical_json"`
+	Format      WebhookFormat      `json:"format"`
+	RetryPolicy WebhookRetryPolicy `json:"retry_policy,omitempty"`
+	RetryDelay  int                `json:"retry_base_delay,omitempty"`
+	MaxAttempts int                `json:"max_attempts,omitempty"`
+	Verified    bool               `json:"verified"`
+	VerifiedAt  *time.Time         `json:"verified_at,omitempty"`
+	ClerkUserID string             `json:"clerk_user_id"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
 }
 
 // WebhookEventResponse represents the webhook event response
@@ -123,11 +262,55 @@ type WebhookEventResponse struct {
 	Delivered    bool             `json:"delivered"`
 	StatusCode   int              `json:"status_code,omitempty"`
 	AttemptCount int              `json:"attempt_count"`
+	DurationMs   int64            `json:"duration_ms,omitempty"`
 	NextRetryAt  *time.Time       `json:"next_retry_at,omitempty"`
+	DedupedInto  *uint            `json:"deduped_into,omitempty"`
+	AckedAt      *time.Time       `json:"acknowledged_at,omitempty"`
+	DeadLetterAt *time.Time       `json:"dead_letter_at,omitempty"`
+	Payload      string           `json:"payload,omitempty"` // Only set when requested with include_payload
 	CreatedAt    time.Time        `json:"created_at"`
 	UpdatedAt    time.Time        `json:"updated_at"`
 }
 
+// WebhookEventSortFields lists the columns webhook events may be sorted by
+var WebhookEventSortFields = []string{"created_at", "status_code", "attempt_count"}
+
+// WebhookEventListOptions controls pagination and sorting of webhook event listings
+type WebhookEventListOptions struct {
+	Limit          int
+	Offset         int
+	Sort           string // one of WebhookEventSortFields
+	Order          string // "asc" or "desc"
+	SinceID        *uint  // Catch-up cursor: only events with a greater ID, oldest first; Offset, Sort and Order are ignored
+	DeadLetter     bool   // Only events whose delivery was given up on
+	IncludePayload bool
+}
+
+// WebhookListOptions controls filtering and pagination of webhook listings
+type WebhookListOptions struct {
+	IsActive  *bool
+	EventType WebhookEventType
+	Limit     int
+	Offset    int
+}
+
+// WebhookLatencyBucket counts deliveries that took longer than the previous bucket's bound and at most UpperMs
+type WebhookLatencyBucket struct {
+	UpperMs *int64 `json:"upper_ms"` // nil for the last bucket, which has no upper bound
+	Count   int    `json:"count"`
+}
+
+// WebhookLatencyHistogram summarizes how long a webhook's receiver took to accept recent deliveries
+type WebhookLatencyHistogram struct {
+	WebhookID  uint                   `json:"webhook_id"`
+	Since      time.Time              `json:"since"`
+	Deliveries int                    `json:"deliveries"`
+	AvgMs      int64                  `json:"avg_ms"`
+	MaxMs      int64                  `json:"max_ms"`
+	TimeoutMs  int64                  `json:"timeout_ms"` // Deliveries taking longer than this fail
+	Buckets    []WebhookLatencyBucket `json:"buckets"`
+}
+
 // JobWebhookPayload represents the payload sent to webhooks for job events
 type JobWebhookPayload struct {
 	Event     WebhookEventType   `json:"event"`
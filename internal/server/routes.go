@@ -3,7 +3,10 @@ package server
 import (
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
+	"ignis/internal/config"
 	"ignis/internal/controllers"
 	"ignis/internal/middleware"
 	"ignis/internal/models"
@@ -14,12 +17,17 @@ import (
 )
 
 func (s *Server) RegisterRoutes() http.Handler {
-	r := gin.Default()
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.Use(middleware.RequestID())
+	r.Use(middleware.RequestLogger())
+	r.Use(middleware.RequestMetrics())
 
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000"},
 		AllowMethods:     []string{"PUT", "PATCH", "POST", "GET", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Content-Type", "Authorization", "Accept", "Origin", "X-Requested-With", "X-API-Key"},
+		AllowHeaders:     []string{"Content-Type", "Authorization", "Accept", "Origin", "X-Requested-With", "X-API-Key", middleware.RequestTimeoutHeader, middleware.TimezoneHeader, middleware.RequestIDHeader},
+		ExposeHeaders:    []string{middleware.RequestIDHeader},
 		AllowCredentials: true,
 	}))
 
@@ -30,7 +38,7 @@ func (s *Server) RegisterRoutes() http.Handler {
 	dbService := services.NewDBService(s.db)
 
 	// Run migrations for all models
-	err := dbService.AutoMigrate(&models.Job{}, &models.APIKey{}, &models.Webhook{}, &models.WebhookEvent{})
+	err := dbService.AutoMigrate(&models.Job{}, &models.APIKey{}, &models.Webhook{}, &models.WebhookEvent{}, &models.WebhookEventAttempt{}, &models.JobComment{}, &models.JobSchedule{}, &models.JobOutput{}, &models.JobArtifact{}, &models.UserQuota{})
 	if err != nil {
 		panic("Failed to run migrations: " + err.Error())
 	}
@@ -41,12 +49,22 @@ func (s *Server) RegisterRoutes() http.Handler {
 		redisURL = "" // Will fall back to in-memory
 	}
 	rateLimiterService := services.NewRateLimiterService(redisURL)
+	shutdownHooks = append(shutdownHooks, rateLimiterService.SaveSnapshot)
+
+	// Initialize policy service
+	policyService := services.NewPolicyService(dbService)
 
 	// Initialize API key service
-	apiKeyService := services.NewAPIKeyService(dbService)
+	apiKeyService := services.NewAPIKeyService(dbService, policyService)
 
 	// Initialize webhook service
-	webhookService := services.NewWebhookService(dbService)
+	webhookService := services.NewWebhookService(dbService, rateLimiterService, policyService)
+
+	// Initialize config service for exports and imports of webhooks and API keys
+	configService := services.NewConfigService(dbService, apiKeyService, webhookService)
+
+	// Initialize job comment service
+	jobCommentService := services.NewJobCommentService(dbService)
 
 	// Initialize job service with webhook service
 	natsURL := os.Getenv("NATS_URL")
@@ -54,16 +72,24 @@ func (s *Server) RegisterRoutes() http.Handler {
 		natsURL = "nats://localhost:4222"
 	}
 
-	jobService, err := services.NewJobService(dbService, natsURL, webhookService)
+	jobService, err := services.NewJobService(dbService, natsURL, webhookService, policyService)
 	if err != nil {
 		panic("Failed to initialize job service: " + err.Error())
 	}
+	s.jobService = jobService
 
 	// Initialize controllers
 	jobController := controllers.NewJobController(jobService)
 	apiKeyController := controllers.NewAPIKeyController(apiKeyService)
 	webhookController := controllers.NewWebhookController(webhookService)
-	publicAPIController := controllers.NewPublicAPIController(jobService)
+	publicAPIController := controllers.NewPublicAPIController(jobService, rateLimiterService)
+	jobCommentController := controllers.NewJobCommentController(jobCommentService)
+	jobArtifactController := controllers.NewJobArtifactController(jobService)
+	scheduleController := controllers.NewScheduleController(jobService)
+	adminController := controllers.NewAdminController(jobService, apiKeyService, rateLimiterService)
+	accountController := controllers.NewAccountController(policyService, rateLimiterService)
+	metricsController := controllers.NewMetricsController(jobService)
+	configController := controllers.NewConfigController(configService)
 
 	// Initialize middleware
 	apiKeyMiddleware := middleware.NewAPIKeyAuthMiddleware(apiKeyService, rateLimiterService)
@@ -72,16 +98,46 @@ func (s *Server) RegisterRoutes() http.Handler {
 	// Health routes (public)
 	r.GET("/", s.HelloWorldHandler)
 	r.GET("/health", s.healthHandler)
+	r.GET("/metrics", metricsController.GetMetrics)
+	r.GET("/health/deep",
+		middleware.RequireClerkAuth(),
+		middleware.RequireAdmin(),
+		rateLimitMiddleware.GlobalRateLimit(config.GetEnvInt("HEALTH_DEEP_RATE_LIMIT", 6), time.Minute),
+		adminController.DeepHealthCheck,
+	)
 
 	// API v1 routes
 	v1 := r.Group("/api/v1")
 	v1.Use(rateLimitMiddleware.StandardGlobalRateLimit()) // Apply global rate limiting
+	v1.Use(middleware.RequestTimeout(config.GetEnvDuration("REQUEST_TIMEOUT_MAX", 30*time.Second)))
+	v1.Use(middleware.Timezone())
+	v1.Use(middleware.ResponseCasing())
 	{
 		// Public routes (no authentication required)
 		public := v1.Group("/public")
 		{
 			public.GET("/health", s.healthHandler)
 			public.GET("/status", publicAPIController.GetAPIStatus)
+			public.GET("/languages", publicAPIController.GetLanguages)
+			public.GET("/worker-versions", publicAPIController.GetWorkerVersions)
+			if config.GetEnvBool("LANGUAGE_STATS_PUBLIC", true) {
+				public.GET("/stats/languages", publicAPIController.GetLanguageLeaderboard)
+			}
+			if config.GetEnvBool("STATS_OVERVIEW_PUBLIC", true) {
+				public.GET("/stats/overview", publicAPIController.GetStatsOverview)
+			}
+		}
+
+		// Account limits accept either Clerk auth or API key auth
+		v1.GET("/public/account/limits", middleware.FlexibleAuth(apiKeyMiddleware), accountController.GetLimits)
+
+		// Config export/import creates API keys, so like key management it needs Clerk auth
+		configRoutes := v1.Group("/public/config")
+		configRoutes.Use(middleware.RequireClerkAuth())
+		configRoutes.Use(rateLimitMiddleware.StandardUserRateLimit())
+		{
+			configRoutes.GET("/export", configController.ExportConfig)
+			configRoutes.POST("/import", configController.ImportConfig)
 		}
 
 		// Public API routes (API key authentication required)
@@ -89,8 +145,23 @@ func (s *Server) RegisterRoutes() http.Handler {
 		publicAPI.Use(apiKeyMiddleware.RequireAPIKeyAuth())
 		{
 			publicAPI.POST("/execute", publicAPIController.ExecuteCode)
+			publicAPI.POST("/execute/estimate", publicAPIController.EstimateCost)
+			publicAPI.POST("/execute/batch", publicAPIController.ExecuteBatch)
 			publicAPI.GET("/jobs", publicAPIController.GetMyJobs)
 			publicAPI.GET("/jobs/:job_id", publicAPIController.GetJobStatus)
+			publicAPI.POST("/jobs/status", publicAPIController.GetJobStatuses)
+			publicAPI.GET("/jobs/scheduled", publicAPIController.GetScheduledJobs)
+			publicAPI.DELETE("/jobs/scheduled/:job_id", publicAPIController.CancelScheduledJob)
+			publicAPI.POST("/jobs/:job_id/cancel", publicAPIController.CancelJob)
+			publicAPI.GET("/jobs/stats/by-language", publicAPIController.GetJobCountsByLanguage)
+
+			publicAPI.GET("/account", accountController.GetAccount)
+
+			publicAPI.POST("/schedules", scheduleController.CreateSchedule)
+			publicAPI.GET("/schedules", scheduleController.GetSchedules)
+			publicAPI.GET("/schedules/:id", scheduleController.GetSchedule)
+			publicAPI.PATCH("/schedules/:id", scheduleController.UpdateSchedule)
+			publicAPI.DELETE("/schedules/:id", scheduleController.DeleteSchedule)
 		}
 
 		// Protected routes (require Clerk authentication only - for API key/webhook management)
@@ -103,6 +174,7 @@ func (s *Server) RegisterRoutes() http.Handler {
 			{
 				apiKeys.POST("", apiKeyController.CreateAPIKey)
 				apiKeys.GET("", apiKeyController.GetAPIKeys)
+				apiKeys.PATCH("/bulk", apiKeyController.BulkUpdateAPIKeys)
 				apiKeys.GET("/:id", apiKeyController.GetAPIKey)
 				apiKeys.PATCH("/:id", apiKeyController.UpdateAPIKey)
 				apiKeys.DELETE("/:id", apiKeyController.DeleteAPIKey)
@@ -113,13 +185,35 @@ func (s *Server) RegisterRoutes() http.Handler {
 			{
 				webhooks.POST("", webhookController.CreateWebhook)
 				webhooks.GET("", webhookController.GetWebhooks)
+				webhooks.GET("/event-types", webhookController.GetEventTypes)
 				webhooks.GET("/:id", webhookController.GetWebhook)
 				webhooks.PATCH("/:id", webhookController.UpdateWebhook)
 				webhooks.DELETE("/:id", webhookController.DeleteWebhook)
+				webhooks.POST("/:id/verify", webhookController.VerifyWebhook)
+				webhooks.POST("/:id/rotate-secret", webhookController.RotateWebhookSecret)
 				webhooks.GET("/:id/events", webhookController.GetWebhookEvents)
+				webhooks.POST("/:id/events/ack", webhookController.AcknowledgeWebhookEvents)
+				webhooks.POST("/:id/events/:event_id/ack", webhookController.AcknowledgeWebhookEvent)
+				webhooks.GET("/:id/latency", webhookController.GetWebhookLatency)
 			}
 		}
 
+		// Admin routes (require Clerk authentication and an ADMIN_USER_IDS entry)
+		admin := v1.Group("/admin")
+		admin.Use(middleware.RequireClerkAuth())
+		admin.Use(middleware.RequireAdmin())
+		{
+			admin.GET("/jobs/undelivered-webhooks", adminController.GetJobsWithUndeliveredWebhooks)
+			admin.GET("/jobs/:job_id", adminController.GetJob)
+			admin.POST("/jobs/:job_id/force-status", adminController.ForceJobStatus)
+			admin.GET("/stats/in-flight", adminController.GetInFlightJobs)
+			admin.GET("/queue", adminController.GetQueueDepth)
+			admin.GET("/stats/languages", publicAPIController.GetLanguageLeaderboard)
+			admin.GET("/rate-limit/inspect", adminController.InspectRateLimit)
+			admin.PUT("/users/:user_id/tier", accountController.SetUserTier)
+			admin.PUT("/api-keys/:id/tier", adminController.SetAPIKeyTier)
+		}
+
 		// Flexible auth routes (accept either Clerk auth or API key auth)
 		flexible := v1.Group("/")
 		flexible.Use(middleware.FlexibleAuth(apiKeyMiddleware))
@@ -128,9 +222,21 @@ func (s *Server) RegisterRoutes() http.Handler {
 			jobs := flexible.Group("/jobs")
 			{
 				jobs.POST("", jobController.CreateJob)
+				jobs.POST("/import", jobController.ImportJobs)
 				jobs.GET("/my", jobController.GetMyJobs)
+				jobs.GET("/search", jobController.SearchJobs)
+				jobs.GET("/scheduled", jobController.GetScheduledJobs)
+				jobs.DELETE("/scheduled/:job_id", jobController.CancelScheduledJob)
+				jobs.GET("/export.csv", jobController.ExportJobsCSV)
+				jobs.GET("/undelivered-webhooks", jobController.GetJobsWithUndeliveredWebhooks)
 				jobs.GET("/:id", jobController.GetJob)
 				jobs.GET("/job_id/:job_id", jobController.GetJobByJobID)
+				jobs.POST("/:id/comments", jobCommentController.CreateComment)
+				jobs.GET("/:id/comments", jobCommentController.GetComments)
+				jobs.GET("/:id/artifacts", jobArtifactController.GetArtifacts)
+				jobs.GET("/:id/artifacts/:artifact_id", jobArtifactController.DownloadArtifact)
+				jobs.POST("/:id/cancel", jobController.CancelJob)
+				jobs.POST("/:id/revalidate", jobController.RevalidateJob)
 			}
 		}
 	}
@@ -146,5 +252,11 @@ func (s *Server) HelloWorldHandler(c *gin.Context) {
 }
 
 func (s *Server) healthHandler(c *gin.Context) {
-	c.JSON(http.StatusOK, s.db.Health())
+	health := s.db.Health()
+	if s.jobService != nil {
+		if depth, err := s.jobService.QueueDepth(); err == nil {
+			health["queue_depth"] = strconv.FormatInt(depth, 10)
+		}
+	}
+	c.JSON(http.StatusOK, health)
 }
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
@@ -8,17 +9,38 @@ import (
 	"time"
 
 	_ "github.com/joho/godotenv/autoload"
+	log "github.com/sirupsen/logrus"
 
+	"ignis/internal/config"
 	"ignis/internal/database"
+	"ignis/internal/services"
 )
 
 type Server struct {
 	port int
 
 	db database.Service
+
+	jobService *services.JobService // Set by RegisterRoutes; reports the queue depth on /health
+}
+
+// shutdownHooks hold cleanup registered while building the routes, run by RunShutdownHooks
+var shutdownHooks []func(ctx context.Context)
+
+// RunShutdownHooks runs the registered cleanup, such as saving the in-memory rate limiter state.
+// Call it once the HTTP server has shut down.
+func RunShutdownHooks(ctx context.Context) {
+	for _, hook := range shutdownHooks {
+		hook(ctx)
+	}
 }
 
 func NewServer() *http.Server {
+	// Apply the configured log level before anything logs
+	if level, err := log.ParseLevel(config.GetEnv("LOG_LEVEL", "info")); err == nil {
+		log.SetLevel(level)
+	}
+
 	port, _ := strconv.Atoi(os.Getenv("PORT"))
 	NewServer := &Server{
 		port: port,
@@ -26,13 +48,15 @@ func NewServer() *http.Server {
 		db: database.New(),
 	}
 
-	// Declare Server config
+	// Declare Server config. The read timeouts cut off clients that trickle in headers or a
+	// request body (slowloris), so they can't hold connections open indefinitely.
 	server := &http.Server{
-		Addr:         fmt.Sprintf(":%d", NewServer.port),
-		Handler:      NewServer.RegisterRoutes(),
-		IdleTimeout:  time.Minute,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 30 * time.Second,
+		Addr:              fmt.Sprintf(":%d", NewServer.port),
+		Handler:           NewServer.RegisterRoutes(),
+		IdleTimeout:       config.GetEnvDuration("SERVER_IDLE_TIMEOUT", time.Minute),
+		ReadHeaderTimeout: config.GetEnvDuration("SERVER_READ_HEADER_TIMEOUT", 5*time.Second),
+		ReadTimeout:       config.GetEnvDuration("SERVER_READ_TIMEOUT", 10*time.Second),
+		WriteTimeout:      config.GetEnvDuration("SERVER_WRITE_TIMEOUT", 30*time.Second),
 	}
 
 	return server
@@ -5,25 +5,43 @@ import (
 	"fmt"
 	"time"
 
+	"ignis/internal/config"
 	"ignis/internal/models"
 
 	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 )
 
 // APIKeyService handles business logic for API keys
 type APIKeyService struct {
-	dbService *DBService
+	dbService   *DBService
+	policy      *PolicyService
+	maxLifetime time.Duration // Maximum time until expiry a key may be created with; zero means unlimited
 }
 
 // NewAPIKeyService creates a new instance of APIKeyService
-func NewAPIKeyService(dbService *DBService) *APIKeyService {
+func NewAPIKeyService(dbService *DBService, policy *PolicyService) *APIKeyService {
 	return &APIKeyService{
-		dbService: dbService,
+		dbService:   dbService,
+		policy:      policy,
+		maxLifetime: config.GetEnvDuration("API_KEY_MAX_LIFETIME", 0),
 	}
 }
 
-// CreateAPIKey creates a new API key for a user
+// CreateAPIKey creates a new API key for a user. Without an expires_at the key expires after the
+// maximum lifetime, if one is configured.
 func (s *APIKeyService) CreateAPIKey(req models.APIKeyCreateRequest, clerkUserID string) (*models.APIKeyCreateResponse, error) {
+	if err := s.validateExpiresAt(req.ExpiresAt); err != nil {
+		return nil, err
+	}
+	if req.ExpiresAt == nil && s.maxLifetime > 0 {
+		expiresAt := time.Now().Add(s.maxLifetime)
+		req.ExpiresAt = &expiresAt
+	}
+	if err := s.policy.CheckAPIKeyCount(clerkUserID); err != nil {
+		return nil, err
+	}
+
 	// Generate raw API key
 	rawKey, err := models.GenerateAPIKey()
 	if err != nil {
@@ -36,6 +54,8 @@ func (s *APIKeyService) CreateAPIKey(req models.APIKeyCreateRequest, clerkUserID
 	// Extract prefix for identification (first 16 chars including "ign_")
 	keyPrefix := rawKey[:16]
 
+	rateLimit, unlimited := apiKeyRateLimit(s.policy.LimitsFor(clerkUserID), req.Unlimited)
+
 	// Create API key record
 	apiKey := models.APIKey{
 		Name:        req.Name,
@@ -43,7 +63,9 @@ func (s *APIKeyService) CreateAPIKey(req models.APIKeyCreateRequest, clerkUserID
 		KeyPrefix:   keyPrefix,
 		ClerkUserID: clerkUserID,
 		IsActive:    true,
-		RateLimit:   5,
+		RateLimit:   rateLimit,
+		Unlimited:   unlimited,
+		Metadata:    req.Metadata,
 		ExpiresAt:   req.ExpiresAt,
 	}
 
@@ -57,6 +79,7 @@ func (s *APIKeyService) CreateAPIKey(req models.APIKeyCreateRequest, clerkUserID
 		"name":          apiKey.Name,
 		"clerk_user_id": clerkUserID,
 		"rate_limit":    apiKey.RateLimit,
+		"unlimited":     apiKey.Unlimited,
 	}).Info("API key created")
 
 	// Return response with raw key (only time it's exposed)
@@ -68,6 +91,8 @@ func (s *APIKeyService) CreateAPIKey(req models.APIKeyCreateRequest, clerkUserID
 			ClerkUserID: apiKey.ClerkUserID,
 			IsActive:    apiKey.IsActive,
 			RateLimit:   apiKey.RateLimit,
+			Unlimited:   apiKey.Unlimited,
+			Metadata:    apiKey.Metadata,
 			ExpiresAt:   apiKey.ExpiresAt,
 			CreatedAt:   apiKey.CreatedAt,
 			UpdatedAt:   apiKey.UpdatedAt,
@@ -78,20 +103,46 @@ func (s *APIKeyService) CreateAPIKey(req models.APIKeyCreateRequest, clerkUserID
 	return response, nil
 }
 
-// GetAPIKeysByUser retrieves all API keys for a user
-func (s *APIKeyService) GetAPIKeysByUser(clerkUserID string) ([]models.APIKeyResponse, error) {
+// apiKeyRateLimit returns the rate limit a new key gets from its owner's tier, and whether it is
+// unlimited. A tier rate limit of 0 means unlimited, whereas the rate limiter allows nothing at 0.
+func apiKeyRateLimit(limits models.TierLimits, requestedUnlimited bool) (int, bool) {
+	if limits.APIKeyRateLimit <= 0 {
+		return 0, true
+	}
+	return limits.APIKeyRateLimit, requestedUnlimited
+}
+
+// GetAPIKeysByUser retrieves a filtered page of API keys for a user along with the total match count
+func (s *APIKeyService) GetAPIKeysByUser(clerkUserID string, opts models.APIKeyListOptions) ([]models.APIKeyResponse, int64, error) {
+	query := s.dbService.GetDB().Model(&models.APIKey{}).Where("clerk_user_id = ?", clerkUserID)
+
+	if opts.IsActive != nil {
+		query = query.Where("is_active = ?", *opts.IsActive)
+	}
+	if opts.Expired != nil {
+		now := time.Now()
+		if *opts.Expired {
+			query = query.Where("expires_at IS NOT NULL AND expires_at < ?", now)
+		} else {
+			query = query.Where("(expires_at IS NULL OR expires_at >= ?)", now)
+		}
+	}
+	for key, value := range opts.Metadata {
+		query = query.Where("metadata ->> ? = ?", key, value)
+	}
+
 	var apiKeys []models.APIKey
-	err := s.dbService.FindWhere(&apiKeys, "clerk_user_id = ?", clerkUserID)
+	total, err := findPage(query, &apiKeys, "created_at DESC, id DESC", opts.Limit, opts.Offset)
 	if err != nil {
-		return nil, err
+		return nil, 0, fmt.Errorf("failed to fetch API keys: %w", err)
 	}
 
-	var responses []models.APIKeyResponse
+	responses := make([]models.APIKeyResponse, 0, len(apiKeys))
 	for _, apiKey := range apiKeys {
 		responses = append(responses, s.toAPIKeyResponse(apiKey))
 	}
 
-	return responses, nil
+	return responses, total, nil
 }
 
 // GetAPIKeyByID retrieves an API key by ID for a specific user
@@ -99,19 +150,28 @@ func (s *APIKeyService) GetAPIKeyByID(id uint, clerkUserID string) (*models.APIK
 	var apiKey models.APIKey
 	err := s.dbService.FindOne(&apiKey, "id = ? AND clerk_user_id = ?", id, clerkUserID)
 	if err != nil {
-		return nil, fmt.Errorf("API key not found")
+		return nil, ErrAPIKeyNotFound
 	}
 
 	response := s.toAPIKeyResponse(apiKey)
 	return &response, nil
 }
 
+// GetRateLimit returns the per-minute rate limit configured on any user's API key, for operator diagnostics
+func (s *APIKeyService) GetRateLimit(id uint) (int, error) {
+	var apiKey models.APIKey
+	if err := s.dbService.FindOne(&apiKey, "id = ?", id); err != nil {
+		return 0, ErrAPIKeyNotFound
+	}
+	return apiKey.RateLimit, nil
+}
+
 // DeleteAPIKey soft deletes an API key
 func (s *APIKeyService) DeleteAPIKey(id uint, clerkUserID string) error {
 	var apiKey models.APIKey
 	err := s.dbService.FindOne(&apiKey, "id = ? AND clerk_user_id = ?", id, clerkUserID)
 	if err != nil {
-		return fmt.Errorf("API key not found")
+		return ErrAPIKeyNotFound
 	}
 
 	err = s.dbService.Delete(&apiKey, apiKey.ID)
@@ -128,14 +188,19 @@ func (s *APIKeyService) DeleteAPIKey(id uint, clerkUserID string) error {
 }
 
 // UpdateAPIKey updates an API key's properties
-func (s *APIKeyService) UpdateAPIKey(id uint, clerkUserID string, isActive bool) error {
+func (s *APIKeyService) UpdateAPIKey(id uint, clerkUserID string, req models.APIKeyUpdateRequest) error {
 	var apiKey models.APIKey
 	err := s.dbService.FindOne(&apiKey, "id = ? AND clerk_user_id = ?", id, clerkUserID)
 	if err != nil {
-		return fmt.Errorf("API key not found")
+		return ErrAPIKeyNotFound
 	}
 
-	apiKey.IsActive = isActive
+	if req.IsActive != nil {
+		apiKey.IsActive = *req.IsActive
+	}
+	if req.Metadata != nil {
+		apiKey.Metadata = req.Metadata
+	}
 	err = s.dbService.Update(&apiKey)
 	if err != nil {
 		return fmt.Errorf("failed to update API key: %w", err)
@@ -144,18 +209,97 @@ func (s *APIKeyService) UpdateAPIKey(id uint, clerkUserID string, isActive bool)
 	log.WithFields(log.Fields{
 		"api_key_id":    id,
 		"clerk_user_id": clerkUserID,
-		"is_active":     isActive,
+		"is_active":     apiKey.IsActive,
 	}).Info("API key updated")
 
 	return nil
 }
 
+// SetTier assigns the tier that sets the job priorities of any user's API key
+func (s *APIKeyService) SetTier(id uint, tier models.UserTier) (*models.APIKeyResponse, error) {
+	var apiKey models.APIKey
+	if err := s.dbService.FindOne(&apiKey, "id = ?", id); err != nil {
+		return nil, ErrAPIKeyNotFound
+	}
+
+	err := s.dbService.GetDB().Model(&apiKey).UpdateColumn("tier", tier).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to set API key tier: %w", err)
+	}
+	apiKey.Tier = tier
+
+	log.WithFields(log.Fields{
+		"api_key_id":    id,
+		"clerk_user_id": apiKey.ClerkUserID,
+		"tier":          tier,
+	}).Info("API key tier updated")
+
+	response := s.toAPIKeyResponse(apiKey)
+	return &response, nil
+}
+
+// BulkSetActive enables or disables all of the given keys owned by the user in a single
+// transaction. IDs the user doesn't own are skipped and reported rather than failing the batch.
+func (s *APIKeyService) BulkSetActive(ids []uint, clerkUserID string, active bool) (*models.APIKeyBulkUpdateResult, error) {
+	result := &models.APIKeyBulkUpdateResult{NotFound: make([]uint, 0)}
+
+	err := s.dbService.Transaction(func(tx *gorm.DB) error {
+		var ownedIDs []uint
+		err := tx.Model(&models.APIKey{}).
+			Where("id IN ? AND clerk_user_id = ?", ids, clerkUserID).
+			Pluck("id", &ownedIDs).Error
+		if err != nil {
+			return err
+		}
+
+		owned := make(map[uint]bool, len(ownedIDs))
+		for _, id := range ownedIDs {
+			owned[id] = true
+		}
+		for _, id := range ids {
+			if !owned[id] {
+				result.NotFound = append(result.NotFound, id)
+			}
+		}
+		if len(ownedIDs) == 0 {
+			return nil
+		}
+
+		update := tx.Model(&models.APIKey{}).
+			Where("id IN ? AND is_active <> ?", ownedIDs, active).
+			Update("is_active", active)
+		if update.Error != nil {
+			return update.Error
+		}
+		result.Updated = update.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update API keys: %w", err)
+	}
+
+	log.WithFields(log.Fields{
+		"clerk_user_id": clerkUserID,
+		"is_active":     active,
+		"requested":     len(ids),
+		"updated":       result.Updated,
+		"not_found":     len(result.NotFound),
+	}).Info("API keys bulk updated")
+
+	return result, nil
+}
+
 // ValidateAPIKey validates an API key and returns the associated user info
 func (s *APIKeyService) ValidateAPIKey(rawKey string) (*models.APIKey, error) {
 	if rawKey == "" {
 		return nil, fmt.Errorf("API key is required")
 	}
 
+	// Reject malformed keys before spending a hash and a DB lookup on them
+	if !models.IsWellFormedAPIKey(rawKey) {
+		return nil, fmt.Errorf("invalid API key")
+	}
+
 	// Hash the provided key
 	keyHash := s.hashAPIKey(rawKey)
 
@@ -179,6 +323,23 @@ func (s *APIKeyService) ValidateAPIKey(rawKey string) (*models.APIKey, error) {
 	return &apiKey, nil
 }
 
+// validateExpiresAt ensures a requested expiry is in the future and within the configured maximum lifetime
+func (s *APIKeyService) validateExpiresAt(expiresAt *time.Time) error {
+	if expiresAt == nil {
+		return nil
+	}
+
+	now := time.Now()
+	if !expiresAt.After(now) {
+		return invalidInput("expires_at must be in the future")
+	}
+	if s.maxLifetime > 0 && expiresAt.After(now.Add(s.maxLifetime)) {
+		return invalidInput("expires_at must be within %s from now", s.maxLifetime)
+	}
+
+	return nil
+}
+
 // hashAPIKey creates a SHA256 hash of the API key
 func (s *APIKeyService) hashAPIKey(rawKey string) string {
 	hasher := sha256.New()
@@ -195,8 +356,12 @@ func (s *APIKeyService) toAPIKeyResponse(apiKey models.APIKey) models.APIKeyResp
 		ClerkUserID: apiKey.ClerkUserID,
 		IsActive:    apiKey.IsActive,
 		RateLimit:   apiKey.RateLimit,
+		Unlimited:   apiKey.Unlimited,
+		Metadata:    apiKey.Metadata,
+		Tier:        apiKey.Tier,
 		LastUsedAt:  apiKey.LastUsedAt,
 		ExpiresAt:   apiKey.ExpiresAt,
+		Expired:     apiKey.IsExpired(),
 		CreatedAt:   apiKey.CreatedAt,
 		UpdatedAt:   apiKey.UpdatedAt,
 	}
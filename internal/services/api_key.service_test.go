@@ -0,0 +1,31 @@
+package services
+
+import (
+	"testing"
+
+	"ignis/internal/models"
+)
+
+func TestAPIKeyRateLimit(t *testing.T) {
+	tests := []struct {
+		name               string
+		tierRateLimit      int
+		requestedUnlimited bool
+		wantRateLimit      int
+		wantUnlimited      bool
+	}{
+		{name: "limited tier", tierRateLimit: 60, wantRateLimit: 60, wantUnlimited: false},
+		{name: "limited tier, unlimited requested", tierRateLimit: 60, requestedUnlimited: true, wantRateLimit: 60, wantUnlimited: true},
+		{name: "unlimited tier", tierRateLimit: 0, wantRateLimit: 0, wantUnlimited: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rateLimit, unlimited := apiKeyRateLimit(models.TierLimits{APIKeyRateLimit: tt.tierRateLimit}, tt.requestedUnlimited)
+			if rateLimit != tt.wantRateLimit || unlimited != tt.wantUnlimited {
+				t.Errorf("expected rate_limit=%d unlimited=%v, got rate_limit=%d unlimited=%v",
+					tt.wantRateLimit, tt.wantUnlimited, rateLimit, unlimited)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"ignis/internal/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ConfigService exports and imports a user's webhooks and API keys
+type ConfigService struct {
+	dbService      *DBService
+	apiKeyService  *APIKeyService
+	webhookService *WebhookService
+}
+
+// NewConfigService creates a new config service
+func NewConfigService(dbService *DBService, apiKeyService *APIKeyService, webhookService *WebhookService) *ConfigService {
+	return &ConfigService{
+		dbService:      dbService,
+		apiKeyService:  apiKeyService,
+		webhookService: webhookService,
+	}
+}
+
+// ExportConfig returns the user's webhooks and API keys without secrets or raw keys
+func (s *ConfigService) ExportConfig(clerkUserID string) (*models.ConfigExport, error) {
+	var webhooks []models.Webhook
+	if err := s.dbService.GetDB().Where("clerk_user_id = ?", clerkUserID).Order("id").Find(&webhooks).Error; err != nil {
+		return nil, fmt.Errorf("failed to load webhooks: %w", err)
+	}
+
+	var apiKeys []models.APIKey
+	if err := s.dbService.GetDB().Where("clerk_user_id = ?", clerkUserID).Order("id").Find(&apiKeys).Error; err != nil {
+		return nil, fmt.Errorf("failed to load API keys: %w", err)
+	}
+
+	export := &models.ConfigExport{
+		Version:    models.ConfigExportVersion,
+		ExportedAt: time.Now(),
+		Webhooks:   make([]models.WebhookConfigExport, 0, len(webhooks)),
+		APIKeys:    make([]models.APIKeyConfigExport, 0, len(apiKeys)),
+	}
+	for _, webhook := range webhooks {
+		export.Webhooks = append(export.Webhooks, models.WebhookConfigExport{
+			URL:         webhook.URL,
+			Events:      webhook.Events,
+			IsActive:    webhook.IsActive,
+			RateLimit:   webhook.RateLimit,
+			Canonical:   webhook.Canonical,
+			Format:      webhook.Format,
+			RetryPolicy: webhook.RetryPolicy,
+			RetryDelay:  webhook.RetryDelay,
+			MaxAttempts: webhook.MaxAttempts,
+		})
+	}
+	for _, apiKey := range apiKeys {
+		export.APIKeys = append(export.APIKeys, models.APIKeyConfigExport{
+			Name:      apiKey.Name,
+			IsActive:  apiKey.IsActive,
+			Metadata:  apiKey.Metadata,
+			ExpiresAt: apiKey.ExpiresAt,
+		})
+	}
+
+	return export, nil
+}
+
+// ImportConfig recreates exported webhooks and API keys for the user with new secrets and keys.
+// Webhooks for a URL the user already has are skipped, API keys with a taken name are renamed,
+// and anything over the user's limits is skipped; each entry's outcome is reported. Entries that
+// failed to import carry the error in SkipErr, which may not be fit to show to the client.
+func (s *ConfigService) ImportConfig(clerkUserID string, export models.ConfigExport) (*models.ConfigImportResult, error) {
+	if export.Version != models.ConfigExportVersion {
+		return nil, invalidInput("unsupported config version %d", export.Version)
+	}
+
+	var existingURLs []string
+	if err := s.dbService.GetDB().Model(&models.Webhook{}).Where("clerk_user_id = ?", clerkUserID).Pluck("url", &existingURLs).Error; err != nil {
+		return nil, fmt.Errorf("failed to load webhooks: %w", err)
+	}
+	var existingNames []string
+	if err := s.dbService.GetDB().Model(&models.APIKey{}).Where("clerk_user_id = ?", clerkUserID).Pluck("name", &existingNames).Error; err != nil {
+		return nil, fmt.Errorf("failed to load API keys: %w", err)
+	}
+
+	result := &models.ConfigImportResult{
+		Webhooks: make([]models.WebhookImportResult, 0, len(export.Webhooks)),
+		APIKeys:  make([]models.APIKeyImportResult, 0, len(export.APIKeys)),
+	}
+
+	takenURLs := make(map[string]bool, len(existingURLs))
+	for _, url := range existingURLs {
+		takenURLs[url] = true
+	}
+	for _, webhook := range export.Webhooks {
+		outcome := models.WebhookImportResult{URL: webhook.URL}
+		if takenURLs[webhook.URL] {
+			outcome.Skipped = "a webhook for this URL already exists"
+		} else if created, secret, err := s.importWebhook(clerkUserID, webhook); err != nil {
+			log.WithError(err).WithField("url", webhook.URL).Warn("Skipped webhook during config import")
+			outcome.SkipErr = err
+		} else {
+			outcome.Webhook, outcome.Secret = created, secret
+			takenURLs[webhook.URL] = true
+		}
+		result.Webhooks = append(result.Webhooks, outcome)
+	}
+
+	takenNames := make(map[string]bool, len(existingNames))
+	for _, name := range existingNames {
+		takenNames[name] = true
+	}
+	for _, apiKey := range export.APIKeys {
+		outcome := models.APIKeyImportResult{Name: apiKey.Name}
+		apiKey.Name = uniqueAPIKeyName(apiKey.Name, takenNames)
+		if created, err := s.importAPIKey(clerkUserID, apiKey); err != nil {
+			log.WithError(err).WithField("name", apiKey.Name).Warn("Skipped API key during config import")
+			outcome.SkipErr = err
+		} else {
+			outcome.APIKey = created
+			takenNames[apiKey.Name] = true
+		}
+		result.APIKeys = append(result.APIKeys, outcome)
+	}
+
+	log.WithFields(log.Fields{
+		"clerk_user_id": clerkUserID,
+		"webhooks":      len(export.Webhooks),
+		"api_keys":      len(export.APIKeys),
+	}).Info("Config imported")
+
+	return result, nil
+}
+
+// importWebhook creates one exported webhook with a newly generated secret
+func (s *ConfigService) importWebhook(clerkUserID string, webhook models.WebhookConfigExport) (*models.WebhookResponse, string, error) {
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return nil, "", err
+	}
+
+	created, err := s.webhookService.CreateWebhook(models.WebhookCreateRequest{
+		URL:         webhook.URL,
+		Secret:      secret,
+		Events:      webhook.Events,
+		RateLimit:   webhook.RateLimit,
+		Canonical:   webhook.Canonical,
+		Format:      webhook.Format,
+		RetryPolicy: webhook.RetryPolicy,
+		RetryDelay:  webhook.RetryDelay,
+		MaxAttempts: webhook.MaxAttempts,
+	}, clerkUserID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !webhook.IsActive {
+		inactive := false
+		if created, err = s.webhookService.UpdateWebhook(created.ID, clerkUserID, models.WebhookUpdateRequest{IsActive: &inactive}); err != nil {
+			return nil, "", err
+		}
+	}
+	return created, secret, nil
+}
+
+// importAPIKey creates one exported API key under a new raw key
+func (s *ConfigService) importAPIKey(clerkUserID string, apiKey models.APIKeyConfigExport) (*models.APIKeyCreateResponse, error) {
+	created, err := s.apiKeyService.CreateAPIKey(models.APIKeyCreateRequest{
+		Name:      apiKey.Name,
+		ExpiresAt: apiKey.ExpiresAt,
+		Metadata:  apiKey.Metadata,
+	}, clerkUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !apiKey.IsActive {
+		inactive := false
+		if err := s.apiKeyService.UpdateAPIKey(created.ID, clerkUserID, models.APIKeyUpdateRequest{IsActive: &inactive}); err != nil {
+			return nil, err
+		}
+		created.IsActive = false
+	}
+	return created, nil
+}
+
+// uniqueAPIKeyName returns name, or the first of "name (2)", "name (3)", ... that isn't taken,
+// shortened to fit the 100 character limit on key names
+func uniqueAPIKeyName(name string, taken map[string]bool) string {
+	if !taken[name] {
+		return name
+	}
+	for n := 2; ; n++ {
+		suffix := fmt.Sprintf(" (%d)", n)
+		base := name
+		if len(base)+len(suffix) > 100 {
+			base = strings.ToValidUTF8(base[:100-len(suffix)], "")
+		}
+		if candidate := base + suffix; !taken[candidate] {
+			return candidate
+		}
+	}
+}
@@ -1,39 +1,58 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
+	"ignis/internal/config"
 	"ignis/internal/database"
+	"ignis/internal/models"
 
 	"gorm.io/gorm"
 )
 
 // DBService handles all database operations using GORM
 type DBService struct {
-	db database.Service
+	db  database.Service
+	ctx context.Context // Optional request context applied to every query
 }
 
-// NewDBService creates a new instance of DBService
+// NewDBService creates a new instance of DBService. DB_COMPRESS_TEXT turns on gzip compression
+// of large job code and output columns.
 func NewDBService(db database.Service) *DBService {
+	models.SetTextCompression(config.GetEnvBool("DB_COMPRESS_TEXT", false))
+
 	return &DBService{
 		db: db,
 	}
 }
 
+// WithContext returns a DBService whose queries are bound to ctx, so they are
+// cancelled when the request deadline passes
+func (s *DBService) WithContext(ctx context.Context) *DBService {
+	return &DBService{
+		db:  s.db,
+		ctx: ctx,
+	}
+}
+
 // GetDB returns the GORM database instance
 func (s *DBService) GetDB() *gorm.DB {
+	if s.ctx != nil {
+		return s.db.GetDB().WithContext(s.ctx)
+	}
 	return s.db.GetDB()
 }
 
 // AutoMigrate runs auto migration for given models
 func (s *DBService) AutoMigrate(models ...interface{}) error {
-	return s.db.GetDB().AutoMigrate(models...)
+	return s.GetDB().AutoMigrate(models...)
 }
 
 // Create creates a new record in the database
 func (s *DBService) Create(model interface{}) error {
-	result := s.db.GetDB().Create(model)
+	result := s.GetDB().Create(model)
 	if result.Error != nil {
 		return fmt.Errorf("failed to create record: %w", result.Error)
 	}
@@ -42,7 +61,7 @@ func (s *DBService) Create(model interface{}) error {
 
 // GetByID retrieves a record by its ID
 func (s *DBService) GetByID(model interface{}, id interface{}) error {
-	result := s.db.GetDB().First(model, id)
+	result := s.GetDB().First(model, id)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return fmt.Errorf("record not found")
@@ -54,7 +73,7 @@ func (s *DBService) GetByID(model interface{}, id interface{}) error {
 
 // GetAll retrieves all records of a model
 func (s *DBService) GetAll(models interface{}) error {
-	result := s.db.GetDB().Find(models)
+	result := s.GetDB().Find(models)
 	if result.Error != nil {
 		return fmt.Errorf("failed to get records: %w", result.Error)
 	}
@@ -63,7 +82,7 @@ func (s *DBService) GetAll(models interface{}) error {
 
 // Update updates a record in the database
 func (s *DBService) Update(model interface{}) error {
-	result := s.db.GetDB().Save(model)
+	result := s.GetDB().Save(model)
 	if result.Error != nil {
 		return fmt.Errorf("failed to update record: %w", result.Error)
 	}
@@ -72,7 +91,7 @@ func (s *DBService) Update(model interface{}) error {
 
 // Delete deletes a record from the database
 func (s *DBService) Delete(model interface{}, id interface{}) error {
-	result := s.db.GetDB().Delete(model, id)
+	result := s.GetDB().Delete(model, id)
 	if result.Error != nil {
 		return fmt.Errorf("failed to delete record: %w", result.Error)
 	}
@@ -81,7 +100,7 @@ func (s *DBService) Delete(model interface{}, id interface{}) error {
 
 // FindWhere finds records based on conditions
 func (s *DBService) FindWhere(models interface{}, query interface{}, args ...interface{}) error {
-	result := s.db.GetDB().Where(query, args...).Find(models)
+	result := s.GetDB().Where(query, args...).Find(models)
 	if result.Error != nil {
 		return fmt.Errorf("failed to find records: %w", result.Error)
 	}
@@ -90,7 +109,7 @@ func (s *DBService) FindWhere(models interface{}, query interface{}, args ...int
 
 // FindOne finds a single record based on conditions
 func (s *DBService) FindOne(model interface{}, query interface{}, args ...interface{}) error {
-	result := s.db.GetDB().Where(query, args...).First(model)
+	result := s.GetDB().Where(query, args...).First(model)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return fmt.Errorf("record not found")
@@ -100,15 +119,30 @@ func (s *DBService) FindOne(model interface{}, query interface{}, args ...interf
 	return nil
 }
 
+// findPage counts the records matched by query and loads one page of them into dest, in order
+func findPage(query *gorm.DB, dest interface{}, order string, limit int, offset int) (int64, error) {
+	// Start a new session so the count and the page query don't share statement state
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return 0, fmt.Errorf("failed to count records: %w", err)
+	}
+	if err := query.Order(order).Limit(limit).Offset(offset).Find(dest).Error; err != nil {
+		return 0, fmt.Errorf("failed to find records: %w", err)
+	}
+	return total, nil
+}
+
 // Transaction executes a function within a database transaction
 func (s *DBService) Transaction(fn func(*gorm.DB) error) error {
-	return s.db.GetDB().Transaction(fn)
+	return s.GetDB().Transaction(fn)
 }
 
 // Count counts records based on conditions
 func (s *DBService) Count(model interface{}, query interface{}, args ...interface{}) (int64, error) {
 	var count int64
-	result := s.db.GetDB().Model(model).Where(query, args...).Count(&count)
+	result := s.GetDB().Model(model).Where(query, args...).Count(&count)
 	if result.Error != nil {
 		return 0, fmt.Errorf("failed to count records: %w", result.Error)
 	}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// dryRunDatabase is a database.Service whose GORM instance builds statements without running them
+type dryRunDatabase struct {
+	db *gorm.DB
+}
+
+func (d *dryRunDatabase) Health() map[string]string { return map[string]string{"status": "up"} }
+func (d *dryRunDatabase) Close() error              { return nil }
+func (d *dryRunDatabase) GetDB() *gorm.DB           { return d.db }
+
+// newDryRunDBService returns a DBService that never reaches a database, along with the records
+// passed to Create and Save (through Create or Update) so tests can inspect what would be written
+func newDryRunDBService(t *testing.T) (*DBService, *[]interface{}) {
+	t.Helper()
+
+	db, err := gorm.Open(postgres.Open("host=localhost dbname=ignis_test"), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to open dry-run database: %v", err)
+	}
+
+	var written []interface{}
+	capture := func(tx *gorm.DB) {
+		written = append(written, tx.Statement.Dest)
+	}
+	if err := db.Callback().Create().After("gorm:create").Register("test:capture_create", capture); err != nil {
+		t.Fatalf("failed to register create callback: %v", err)
+	}
+	if err := db.Callback().Update().After("gorm:update").Register("test:capture_update", capture); err != nil {
+		t.Fatalf("failed to register update callback: %v", err)
+	}
+
+	return &DBService{db: &dryRunDatabase{db: db}}, &written
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotFound is wrapped by the errors returned for records that don't exist or belong to
+// another user, so handlers can answer 404 without matching each resource
+var ErrNotFound = errors.New("not found")
+
+var (
+	// ErrJobNotFound is returned when the user has no job with the given ID
+	ErrJobNotFound = fmt.Errorf("job %w", ErrNotFound)
+	// ErrWebhookNotFound is returned when the user has no webhook with the given ID
+	ErrWebhookNotFound = fmt.Errorf("webhook %w", ErrNotFound)
+	// ErrAPIKeyNotFound is returned when the user has no API key with the given ID
+	ErrAPIKeyNotFound = fmt.Errorf("API key %w", ErrNotFound)
+	// ErrScheduleNotFound is returned when the user has no schedule with the given ID
+	ErrScheduleNotFound = fmt.Errorf("schedule %w", ErrNotFound)
+	// ErrArtifactNotFound is returned when a job has no artifact with the given ID
+	ErrArtifactNotFound = fmt.Errorf("artifact %w", ErrNotFound)
+)
+
+// InvalidInputError is a problem with the caller's request. Its message is meant for the
+// client, unlike other service errors, which may carry database details.
+type InvalidInputError struct {
+	err error
+}
+
+func (e *InvalidInputError) Error() string {
+	return e.err.Error()
+}
+
+func (e *InvalidInputError) Unwrap() error {
+	return e.err
+}
+
+// invalidInput returns an InvalidInputError with a formatted message; %w keeps the wrapped error
+// matchable with errors.Is
+func invalidInput(format string, args ...interface{}) error {
+	return &InvalidInputError{err: fmt.Errorf(format, args...)}
+}
+
+// IsClientError reports whether err describes a problem with the caller's request, such as
+// invalid input, a missing record or a plan limit, so its message can be shown to them
+func IsClientError(err error) bool {
+	var inputErr *InvalidInputError
+	return errors.As(err, &inputErr) ||
+		errors.Is(err, ErrNotFound) ||
+		errors.Is(err, ErrPolicyLimitReached) ||
+		errors.Is(err, ErrJobCapacityReached) ||
+		errors.Is(err, ErrSubmissionCooldown)
+}
@@ -4,31 +4,131 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
 	"time"
 
+	"ignis/internal/config"
 	"ignis/internal/models"
 
 	"github.com/nats-io/nats.go"
 	"github.com/rs/xid"
 	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 )
 
+// maxJobStatusResults caps how many jobs a single batch status poll returns
+const maxJobStatusResults = 500
+
 // JobService handles business logic for jobs
 type JobService struct {
 	dbService      *DBService
 	natsConn       *nats.Conn
 	ctx            context.Context
 	webhookService *WebhookService
+	policy         *PolicyService
+	pricing        jobPricing
+	inFlight       *inFlightCache
+	languageStats  *languageStatsCache
+	statsOverview  *statsOverviewCache
+	eta            *jobETACache
+	workers        *workerRegistry
+	waiters        *jobWaiters
+	retention      jobRetention
+	maxRunAhead    time.Duration // How far in the future run_at may be
+	maxSchedules   int           // Recurring schedules allowed per user
+
+	publishConfirmTimeout time.Duration // How long to wait for NATS to confirm a job publish
+	outputStore           OutputStore
+	outputThreshold       int           // Outputs larger than this many bytes go to outputStore; 0 keeps them inline
+	maxOutputBytes        int           // Cap on stdout and stderr for languages without their own; 0 means unlimited
+	priorityAging         time.Duration // How long a job waits before its effective priority is raised; 0 disables aging
+	maxInFlight           int           // Received and running jobs allowed system-wide; 0 means unlimited
+	maxRunning            int           // Running jobs allowed system-wide, i.e. the worker fleet's capacity; 0 means unlimited
+	stuckAfter            stuckThresholds
+	artifactLimits        jobArtifactLimits
+	cooldown              *submissionCooldown
+
+	queueUnsupportedLanguages bool // Accept languages missing from the registry instead of rejecting them
+}
+
+// jobPricing holds the configured per-language pricing used for cost estimates
+type jobPricing struct {
+	languagePrices map[string]float64 // base price per job, by language
+	defaultPrice   float64            // base price for languages without an explicit price
+	pricePerKB     float64            // additional price per KB of submitted code
+	currency       string
+	timeoutSeconds int
+	memoryLimitMB  int
+}
+
+// loadJobPricing reads job pricing from the environment
+func loadJobPricing() jobPricing {
+	pricing := jobPricing{
+		languagePrices: make(map[string]float64),
+		defaultPrice:   config.GetEnvFloat("JOB_DEFAULT_PRICE", 0.001),
+		pricePerKB:     config.GetEnvFloat("JOB_PRICE_PER_KB", 0),
+		currency:       config.GetEnv("JOB_PRICE_CURRENCY", "USD"),
+		timeoutSeconds: config.GetEnvInt("WORKER_TIMEOUT", 300),
+		memoryLimitMB:  config.GetEnvInt("MAX_MEMORY_MB", 512),
+	}
+
+	for language, raw := range config.GetEnvMap("JOB_LANGUAGE_PRICES") {
+		price, err := strconv.ParseFloat(raw, 64)
+		if err != nil || price < 0 {
+			log.WithField("language", language).Warn("Ignoring invalid job price")
+			continue
+		}
+		if canonical, err := models.CanonicalLanguage(language); err == nil {
+			language = canonical
+		}
+		pricing.languagePrices[strings.ToLower(language)] = price
+	}
+
+	return pricing
+}
+
+// loadUnsupportedLanguagePolicy reads JOB_UNSUPPORTED_LANGUAGES, "reject" (the default) or "queue",
+// and reports whether jobs in unsupported languages should be queued
+func loadUnsupportedLanguagePolicy() bool {
+	switch policy := config.GetEnv("JOB_UNSUPPORTED_LANGUAGES", "reject"); policy {
+	case "queue":
+		return true
+	case "reject":
+		return false
+	default:
+		log.WithField("policy", policy).Warn("Invalid JOB_UNSUPPORTED_LANGUAGES, rejecting unsupported languages")
+		return false
+	}
 }
 
 // NewJobService creates a new instance of JobService
-func NewJobService(dbService *DBService, natsURL string, webhookService *WebhookService) (*JobService, error) {
+func NewJobService(dbService *DBService, natsURL string, webhookService *WebhookService, policy *PolicyService) (*JobService, error) {
+	// NATS_URL may list several cluster members, comma-separated, so the client can fail over
+	servers := parseNATSServers(natsURL)
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("no NATS server URL configured")
+	}
+
 	// Connect to NATS
-	nc, err := nats.Connect(natsURL, nats.MaxReconnects(-1), nats.ReconnectWait(2*time.Second))
+	nc, err := nats.Connect(strings.Join(servers, ","),
+		nats.MaxReconnects(-1),
+		nats.ReconnectWait(2*time.Second),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			log.WithError(err).Warn("Disconnected from NATS")
+		}),
+		nats.ReconnectHandler(func(conn *nats.Conn) {
+			log.WithField("server", conn.ConnectedUrlRedacted()).Info("Reconnected to NATS")
+		}),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
 	}
+	log.WithFields(log.Fields{
+		"server":  nc.ConnectedUrlRedacted(),
+		"servers": len(servers),
+	}).Info("Connected to NATS")
 
 	ctx := context.Background()
 
@@ -37,57 +137,305 @@ func NewJobService(dbService *DBService, natsURL string, webhookService *Webhook
 		natsConn:       nc,
 		ctx:            ctx,
 		webhookService: webhookService,
+		policy:         policy,
+		pricing:        loadJobPricing(),
+		inFlight:       &inFlightCache{ttl: config.GetEnvDuration("JOB_STATS_CACHE_TTL", 5*time.Second)},
+		waiters:        &jobWaiters{waiters: make(map[string]chan models.Job)},
+		languageStats: &languageStatsCache{
+			ttl:     config.GetEnvDuration("LANGUAGE_STATS_CACHE_TTL", 5*time.Minute),
+			entries: make(map[int]languageStatsEntry),
+		},
+		statsOverview: &statsOverviewCache{
+			ttl:     config.GetEnvDuration("STATS_OVERVIEW_CACHE_TTL", time.Minute),
+			entries: make(map[int]*models.JobStatsOverview),
+		},
+		eta: &jobETACache{
+			ttl:         config.GetEnvDuration("JOB_ETA_CACHE_TTL", time.Minute),
+			concurrency: max(config.GetEnvInt("JOB_ETA_CONCURRENCY", 1), 1),
+		},
+		maxRunAhead:           config.GetEnvDuration("JOB_SCHEDULE_MAX_HORIZON", 30*24*time.Hour),
+		maxSchedules:          config.GetEnvInt("JOB_SCHEDULES_PER_USER", 10),
+		publishConfirmTimeout: config.GetEnvDuration("JOB_PUBLISH_CONFIRM_TIMEOUT", 2*time.Second),
+		outputStore:           newOutputStore(dbService),
+		outputThreshold:       config.GetEnvInt("OUTPUT_STORE_THRESHOLD", 64*1024),
+		maxOutputBytes:        config.GetEnvInt("JOB_OUTPUT_MAX_BYTES", 1024*1024),
+		priorityAging:         config.GetEnvDuration("JOB_PRIORITY_AGING_AFTER", 5*time.Minute),
+		maxInFlight:           config.GetEnvInt("MAX_IN_FLIGHT_JOBS", 0),
+		maxRunning:            config.GetEnvInt("MAX_RUNNING_JOBS", 0),
+		stuckAfter:            loadStuckThresholds(),
+		cooldown:              newSubmissionCooldown(),
+		artifactLimits: jobArtifactLimits{
+			maxBytes:  int64(config.GetEnvInt("JOB_ARTIFACT_MAX_BYTES", 10*1024*1024)),
+			maxPerJob: config.GetEnvInt("JOB_ARTIFACTS_PER_JOB", 20),
+		},
+		retention: jobRetention{
+			code:   config.GetEnvDuration("JOB_CODE_RETENTION", 0),
+			output: config.GetEnvDuration("JOB_OUTPUT_RETENTION", 0),
+		},
+		queueUnsupportedLanguages: loadUnsupportedLanguagePolicy(),
 	}
 
 	// Start listening for job status updates
 	go service.listenForJobStatusUpdates()
 
+	// Track the worker versions jobs can be pinned to
+	service.listenForWorkerHeartbeats()
+
+	// Start the background sweeper for deadlines and other time-based job transitions
+	go service.runJobSweeper(config.GetEnvDuration("JOB_SWEEP_INTERVAL", 15*time.Second))
+
+	// Start the scheduler that releases scheduled jobs to the workers when they are due
+	go service.runJobScheduler(config.GetEnvDuration("JOB_SCHEDULER_INTERVAL", 5*time.Second))
+
 	return service, nil
 }
 
-// CreateJob creates a new job and publishes it to NATS
-func (s *JobService) CreateJob(req models.JobCreateRequest, clerkUserID string) (*models.JobResponse, error) {
+// parseNATSServers splits a comma-separated list of NATS server URLs, dropping empty entries
+func parseNATSServers(raw string) []string {
+	servers := make([]string, 0)
+	for _, server := range strings.Split(raw, ",") {
+		if server = strings.TrimSpace(server); server != "" {
+			servers = append(servers, server)
+		}
+	}
+	return servers
+}
+
+// CreateJob creates a new job and publishes it to NATS, or stores it as scheduled when
+// RunAt is set. The job is not created if ctx is already done, e.g. because the client's
+// request deadline passed.
+func (s *JobService) CreateJob(ctx context.Context, req models.JobCreateRequest, clerkUserID string) (*models.JobResponse, error) {
+	if req.Deadline != nil && !req.Deadline.After(time.Now()) {
+		return nil, invalidInput("deadline must be in the future")
+	}
+	if err := s.validateRunAt(req.RunAt, req.Deadline); err != nil {
+		return nil, err
+	}
+
+	language, warning, err := s.resolveJobLanguage(req.Language)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateWorkerVersion(req.WorkerVersion); err != nil {
+		return nil, err
+	}
+
+	if err := s.policy.CheckJobSubmission(clerkUserID, strings.TrimSpace(req.Code)); err != nil {
+		return nil, err
+	}
+	cooldownKey := submissionCooldownKey(clerkUserID, language, strings.TrimSpace(req.Code))
+	if !req.Internal {
+		if err := s.cooldown.claim(cooldownKey); err != nil {
+			return nil, err
+		}
+	}
+	// Free the claim if the job isn't created, so the user can retry straight away
+	created := false
+	defer func() {
+		if !created && !req.Internal {
+			s.cooldown.release(cooldownKey)
+		}
+	}()
+
 	// Generate unique job ID
 	jobID := xid.New().String()
 
+	status := models.JobStatusReceived
+	if req.RunAt != nil {
+		status = models.JobStatusScheduled
+	}
+
+	// Scheduled jobs aren't sent to the workers yet, so only immediate jobs count against capacity
+	if status == models.JobStatusReceived {
+		if err := s.checkCapacity(); err != nil {
+			return nil, err
+		}
+	}
+
+	priority := req.Priority
+	if priority == "" {
+		priority = models.JobPriorityNormal
+	}
+
 	// Create job in database
 	job := models.Job{
-		JobID:       jobID,
-		Language:    strings.TrimSpace(req.Language),
-		Code:        strings.TrimSpace(req.Code),
-		Status:      models.JobStatusReceived,
-		ClerkUserID: clerkUserID,
+		JobID:             jobID,
+		Language:          language,
+		Name:              strings.TrimSpace(req.Name),
+		Description:       strings.TrimSpace(req.Description),
+		Tags:              req.Tags,
+		Metadata:          req.Metadata,
+		Args:              req.Args,
+		WorkerVersion:     req.WorkerVersion,
+		Code:              models.CompressedText(strings.TrimSpace(req.Code)),
+		CodeHash:          models.CodeHash(strings.TrimSpace(req.Code)),
+		Status:            status,
+		Priority:          priority,
+		EffectivePriority: priority,
+		ClerkUserID:       clerkUserID,
+		APIKeyID:          req.APIKeyID,
+		Ephemeral:         req.Ephemeral,
+		ParentJobID:       req.ParentJobID,
+		DeadlineAt:        req.Deadline,
+		RunAt:             req.RunAt,
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("job submission cancelled: %w", err)
 	}
 
-	err := s.dbService.Create(&job)
+	err = s.dbService.WithContext(ctx).Create(&job)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create job: %w", err)
 	}
+	created = true
 
-	// Publish job to NATS
-	benchJob := models.BenchJob{
-		ID:       jobID,
-		Language: job.Language,
-		Code:     job.Code,
-	}
+	if job.Status == models.JobStatusScheduled {
+		log.WithFields(log.Fields{
+			"job_id":        jobID,
+			"language":      job.Language,
+			"clerk_user_id": job.ClerkUserID,
+			"run_at":        job.RunAt,
+		}).Info("Job scheduled")
 
-	jobData, err := json.Marshal(benchJob)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal job data: %w", err)
+		return s.toCreatedJobResponse(job, warning)
 	}
 
-	err = s.natsConn.Publish("jobs", jobData)
-	if err != nil {
-		return nil, fmt.Errorf("failed to publish job to NATS: %w", err)
+	if err := s.publishJob(&job); err != nil {
+		return nil, err
 	}
 
 	log.WithFields(log.Fields{
 		"job_id":        jobID,
 		"language":      job.Language,
 		"clerk_user_id": job.ClerkUserID,
+		"subject":       job.PublishedSubject,
+		"dispatched":    job.DispatchedAt != nil,
 	}).Info("Job created and published to NATS")
 
-	return s.toJobResponse(job)
+	return s.toCreatedJobResponse(job, warning)
+}
+
+// resolveJobLanguage canonicalizes a submitted language. Languages missing from the registry are
+// rejected unless JOB_UNSUPPORTED_LANGUAGES is "queue", in which case they're passed to the
+// workers as submitted (lowercased) along with a warning for the caller.
+func (s *JobService) resolveJobLanguage(raw string) (string, string, error) {
+	language, err := models.CanonicalLanguage(raw)
+	if err == nil {
+		return language, "", nil
+	}
+	if !s.queueUnsupportedLanguages {
+		return "", "", invalidInput("%w", err)
+	}
+
+	language = strings.ToLower(strings.TrimSpace(raw))
+	if language == "" {
+		return "", "", invalidInput("%w", err)
+	}
+	return language, fmt.Sprintf("language %q is not in the supported languages list; the job was queued and may fail if no worker supports it", language), nil
+}
+
+// toCreatedJobResponse converts a newly submitted job, attaching any warning about its submission
+func (s *JobService) toCreatedJobResponse(job models.Job, warning string) (*models.JobResponse, error) {
+	jobResponse, err := s.toJobResponse(job)
+	if err != nil {
+		return nil, err
+	}
+	jobResponse.Warning = warning
+	return jobResponse, nil
+}
+
+// publishJob sends a job to the workers over NATS, preceded by a warmup hint, and saves the
+// subject it went to. Once the NATS server confirms it received the message, DispatchedAt is set
+// too. An unconfirmed publish isn't an error: the client may still deliver it after reconnecting.
+func (s *JobService) publishJob(job *models.Job) error {
+	// Hint workers to warm up the runtime before the job itself arrives
+	s.publishWarmupHint(*job)
+
+	benchJob := models.BenchJob{
+		ID:            job.JobID,
+		Language:      job.Language,
+		Code:          string(job.Code),
+		Args:          job.Args,
+		WorkerVersion: job.WorkerVersion,
+		DeadlineAt:    job.DeadlineAt,
+		Priority:      job.EffectivePriority,
+	}
+
+	jobData, err := json.Marshal(benchJob)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job data: %w", err)
+	}
+
+	subject := jobSubject(*job)
+	err = s.natsConn.Publish(subject, jobData)
+	if err != nil {
+		return fmt.Errorf("failed to publish job to NATS: %w", err)
+	}
+	job.PublishedSubject = subject
+	published := map[string]interface{}{"published_subject": subject}
+
+	// A flush round-trip confirms the server has the message, not just the client's buffer
+	if err := s.natsConn.FlushTimeout(s.publishConfirmTimeout); err != nil {
+		log.WithError(err).WithField("job_id", job.JobID).Warn("NATS did not confirm job publish")
+	} else {
+		dispatchedAt := time.Now()
+		job.DispatchedAt = &dispatchedAt
+		published["dispatched_at"] = dispatchedAt
+	}
+
+	err = s.dbService.GetDB().Model(&models.Job{}).Where("id = ?", job.ID).UpdateColumns(published).Error
+	if err != nil {
+		log.WithError(err).WithField("job_id", job.JobID).Warn("Failed to record job publish details")
+	}
+
+	return nil
+}
+
+// EstimateCost projects the cost and resource allotment of a submission without running it
+func (s *JobService) EstimateCost(req models.JobCreateRequest) (*models.JobCostEstimate, error) {
+	language, _, err := s.resolveJobLanguage(req.Language)
+	if err != nil {
+		return nil, err
+	}
+
+	price, exists := s.pricing.languagePrices[language]
+	if !exists {
+		price = s.pricing.defaultPrice
+	}
+
+	codeSize := len(strings.TrimSpace(req.Code))
+	cost := price + s.pricing.pricePerKB*float64(codeSize)/1024
+
+	return &models.JobCostEstimate{
+		Language:       language,
+		CodeSizeBytes:  codeSize,
+		EstimatedCost:  math.Round(cost*1e6) / 1e6,
+		Currency:       s.pricing.currency,
+		TimeoutSeconds: s.pricing.timeoutSeconds,
+		MemoryLimitMB:  s.pricing.memoryLimitMB,
+	}, nil
+}
+
+// publishWarmupHint publishes a best-effort warmup.<language> hint; failures never affect submission
+func (s *JobService) publishWarmupHint(job models.Job) {
+	// Skip languages that would produce an invalid NATS subject token
+	if job.Language == "" || strings.ContainsAny(job.Language, " \t\r\n.*>") {
+		return
+	}
+
+	hintData, err := json.Marshal(models.WarmupHint{
+		JobID:    job.JobID,
+		Language: job.Language,
+	})
+	if err != nil {
+		log.WithError(err).Debug("Failed to marshal warmup hint")
+		return
+	}
+
+	if err := s.natsConn.Publish("warmup."+job.Language, hintData); err != nil {
+		log.WithError(err).WithField("language", job.Language).Debug("Failed to publish warmup hint")
+	}
 }
 
 // GetJobByID retrieves a job by ID
@@ -98,18 +446,55 @@ func (s *JobService) GetJobByID(id uint) (*models.JobResponse, error) {
 		return nil, err
 	}
 
-	return s.toJobResponse(job)
+	jobResponse, err := s.toJobResponse(job)
+	if err != nil {
+		return nil, err
+	}
+	s.attachCompletionEstimate(jobResponse)
+	return jobResponse, nil
 }
 
 // GetJobByJobID retrieves a job by job ID
-func (s *JobService) GetJobByJobID(jobID string) (*models.JobResponse, error) {
+func (s *JobService) GetJobByJobID(ctx context.Context, jobID string) (*models.JobResponse, error) {
+	var job models.Job
+	err := s.dbService.WithContext(ctx).FindOne(&job, "job_id = ?", jobID)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, fmt.Errorf("job lookup cancelled: %w", ctxErr)
+		}
+		return nil, ErrJobNotFound
+	}
+
+	jobResponse, err := s.toJobResponse(job)
+	if err != nil {
+		return nil, err
+	}
+	s.attachCompletionEstimate(jobResponse)
+	return jobResponse, nil
+}
+
+// GetJobRouting retrieves a job along with where it was published and where it would be published
+// now, so operators can confirm priority and worker-version routing
+func (s *JobService) GetJobRouting(ctx context.Context, jobID string) (*models.AdminJobResponse, error) {
 	var job models.Job
-	err := s.dbService.FindOne(&job, "job_id = ?", jobID)
+	err := s.dbService.WithContext(ctx).FindOne(&job, "job_id = ?", jobID)
 	if err != nil {
-		return nil, fmt.Errorf("job not found")
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, fmt.Errorf("job lookup cancelled: %w", ctxErr)
+		}
+		return nil, ErrJobNotFound
 	}
 
-	return s.toJobResponse(job)
+	jobResponse, err := s.toJobResponse(job)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AdminJobResponse{
+		JobResponse:     *jobResponse,
+		ExpectedSubject: jobSubject(job),
+		ForcedBy:        job.ForcedBy,
+	}, nil
 }
 
 // GetAllJobs retrieves all jobs
@@ -120,13 +505,9 @@ func (s *JobService) GetAllJobs() ([]models.JobResponse, error) {
 		return nil, err
 	}
 
-	var jobResponses []models.JobResponse
+	jobResponses := make([]models.JobResponse, 0, len(jobs))
 	for _, job := range jobs {
-		jobResponse, err := s.toJobResponse(job)
-		if err != nil {
-			return nil, err
-		}
-		jobResponses = append(jobResponses, *jobResponse)
+		jobResponses = append(jobResponses, s.toJobListResponse(job))
 	}
 
 	return jobResponses, nil
@@ -140,16 +521,150 @@ func (s *JobService) GetJobsByClerkUserID(clerkUserID string) ([]models.JobRespo
 		return nil, err
 	}
 
-	var jobResponses []models.JobResponse
+	jobResponses := make([]models.JobResponse, 0, len(jobs))
+	for _, job := range jobs {
+		jobResponses = append(jobResponses, s.toJobListResponse(job))
+	}
+
+	return jobResponses, nil
+}
+
+// GetJobStatuses retrieves a user's jobs by job ID and/or those updated after the cursor, oldest
+// update first. An empty jobIDs slice matches all of the user's jobs. At most maxJobStatusResults
+// jobs are returned; hasMore reports whether more are waiting past the last one.
+func (s *JobService) GetJobStatuses(clerkUserID string, jobIDs []string, after *models.JobStatusCursor) ([]models.JobResponse, bool, error) {
+	query := s.dbService.GetDB().Where("clerk_user_id = ?", clerkUserID)
+	if len(jobIDs) > 0 {
+		query = query.Where("job_id IN ?", jobIDs)
+	}
+	if after != nil && after.JobID != "" {
+		query = query.Where("updated_at > ? OR (updated_at = ? AND job_id > ?)", after.UpdatedAt, after.UpdatedAt, after.JobID)
+	} else if after != nil {
+		query = query.Where("updated_at > ?", after.UpdatedAt)
+	}
+
+	// Fetch one extra row to learn whether another page follows
+	var jobs []models.Job
+	err := query.Order("updated_at ASC, job_id ASC").Limit(maxJobStatusResults + 1).Find(&jobs).Error
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to fetch job statuses: %w", err)
+	}
+	hasMore := len(jobs) > maxJobStatusResults
+	if hasMore {
+		jobs = jobs[:maxJobStatusResults]
+	}
+
+	jobResponses := make([]models.JobResponse, 0, len(jobs))
 	for _, job := range jobs {
-		jobResponse, err := s.toJobResponse(job)
+		jobResponses = append(jobResponses, s.toJobListResponse(job))
+	}
+
+	return jobResponses, hasMore, nil
+}
+
+// SearchJobs lists a user's jobs matching the filter, newest first
+func (s *JobService) SearchJobs(clerkUserID string, filter models.JobListFilter, limit int, offset int) ([]models.JobResponse, int64, error) {
+	query := s.dbService.GetDB().Model(&models.Job{}).Where("clerk_user_id = ?", clerkUserID)
+	query = applyJobListFilter(query, filter)
+
+	var jobs []models.Job
+	total, err := findPage(query, &jobs, "created_at DESC, id DESC", limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search jobs: %w", err)
+	}
+
+	jobResponses := make([]models.JobResponse, 0, len(jobs))
+	for _, job := range jobs {
+		jobResponses = append(jobResponses, s.toJobListResponse(job))
+	}
+
+	return jobResponses, total, nil
+}
+
+// applyJobListFilter adds the filter's conditions to a jobs query
+func applyJobListFilter(query *gorm.DB, filter models.JobListFilter) *gorm.DB {
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Language != "" {
+		// Match stored canonical names when the filter uses an alias or different casing
+		language, err := models.CanonicalLanguage(filter.Language)
 		if err != nil {
-			return nil, err
+			language = filter.Language
 		}
-		jobResponses = append(jobResponses, *jobResponse)
+		query = query.Where("language = ?", language)
+	}
+	if filter.APIKeyID != nil {
+		query = query.Where("api_key_id = ?", *filter.APIKeyID)
+	}
+	if filter.ParentJobID != "" {
+		query = query.Where("parent_job_id = ?", filter.ParentJobID)
 	}
+	if filter.Search != "" {
+		// Escape LIKE wildcards so the search term is matched literally
+		pattern := "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(filter.Search) + "%"
+		query = query.Where("name ILIKE ? OR description ILIKE ?", pattern, pattern)
+	}
+	return query
+}
 
-	return jobResponses, nil
+// StreamJobsByClerkUserID iterates a user's jobs oldest first using a DB cursor, so large
+// result sets are never buffered in memory. Code and output columns are not loaded.
+func (s *JobService) StreamJobsByClerkUserID(clerkUserID string, filter models.JobListFilter, fn func(job models.Job) error) error {
+	query := s.dbService.GetDB().Model(&models.Job{}).
+		Select("id", "job_id", "language", "status", "exec_duration", "mem_usage", "created_at").
+		Where("clerk_user_id = ?", clerkUserID)
+	query = applyJobListFilter(query, filter)
+
+	rows, err := query.Order("created_at ASC, id ASC").Rows()
+	if err != nil {
+		return fmt.Errorf("failed to query jobs: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var job models.Job
+		if err := s.dbService.GetDB().ScanRows(rows, &job); err != nil {
+			return fmt.Errorf("failed to scan job: %w", err)
+		}
+		if err := fn(job); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// GetJobsWithUndeliveredWebhooks lists jobs that have pending or failed webhook deliveries,
+// most recently attempted first. An empty clerkUserID lists jobs across all users.
+func (s *JobService) GetJobsWithUndeliveredWebhooks(clerkUserID string, limit int, offset int) ([]models.JobDeliveryIssue, int64, error) {
+	query := s.dbService.GetDB().Model(&models.Job{}).
+		Joins("JOIN webhook_events ON webhook_events.job_id = jobs.job_id").
+		Where("webhook_events.delivered = ? AND webhook_events.deduped_into IS NULL", false)
+	if clerkUserID != "" {
+		query = query.Where("jobs.clerk_user_id = ?", clerkUserID)
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Distinct("jobs.id").Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count jobs with undelivered webhooks: %w", err)
+	}
+
+	issues := make([]models.JobDeliveryIssue, 0)
+	err := query.
+		Select("jobs.job_id, jobs.language, jobs.status, jobs.clerk_user_id, jobs.created_at, " +
+			"COUNT(webhook_events.id) AS undelivered_events, MAX(webhook_events.updated_at) AS last_attempt_at").
+		Group("jobs.id").
+		Order("last_attempt_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&issues).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch jobs with undelivered webhooks: %w", err)
+	}
+
+	return issues, total, nil
 }
 
 // GetJobsByStatus retrieves jobs by status
@@ -160,13 +675,9 @@ func (s *JobService) GetJobsByStatus(status models.JobStatus) ([]models.JobRespo
 		return nil, err
 	}
 
-	var jobResponses []models.JobResponse
+	jobResponses := make([]models.JobResponse, 0, len(jobs))
 	for _, job := range jobs {
-		jobResponse, err := s.toJobResponse(job)
-		if err != nil {
-			return nil, err
-		}
-		jobResponses = append(jobResponses, *jobResponse)
+		jobResponses = append(jobResponses, s.toJobListResponse(job))
 	}
 
 	return jobResponses, nil
@@ -205,6 +716,36 @@ func (s *JobService) updateJobStatus(statusUpdate models.JobStatusUpdate) error
 		return fmt.Errorf("job not found: %w", err)
 	}
 
+	// A job failed by the sweeper stays failed even if a worker picks it up or reports back late
+	if job.Status == models.JobStatusFailed && (job.Error == deadlineExceededError || job.Error == stuckJobError) {
+		log.WithField("job_id", statusUpdate.ID).Warn("Ignoring status update for job already failed by the sweeper")
+		return nil
+	}
+
+	// Statuses forced by an admin are final
+	if job.ForcedBy != "" {
+		log.WithField("job_id", statusUpdate.ID).Warn("Ignoring status update for job whose status was forced")
+		return nil
+	}
+
+	// Cancelled jobs stay cancelled if a worker still runs them
+	if job.Status == models.JobStatusCancelled {
+		log.WithField("job_id", statusUpdate.ID).Warn("Ignoring status update for cancelled job")
+		return nil
+	}
+
+	// Aged jobs are published more than once; the first worker to report a job claims it
+	if job.Status != models.JobStatusReceived && job.WorkerID != "" && statusUpdate.WorkerID != "" && statusUpdate.WorkerID != job.WorkerID {
+		log.WithFields(log.Fields{
+			"job_id":    statusUpdate.ID,
+			"worker_id": statusUpdate.WorkerID,
+			"claimed":   job.WorkerID,
+		}).Warn("Ignoring status update from a worker that didn't claim the job")
+		return nil
+	}
+
+	previousStatus := job.Status
+
 	// Map status string to JobStatus enum
 	var status models.JobStatus
 	switch statusUpdate.Status {
@@ -224,14 +765,50 @@ func (s *JobService) updateJobStatus(statusUpdate models.JobStatusUpdate) error
 	job.Status = status
 	job.Message = statusUpdate.Message
 	job.Error = statusUpdate.Error
-	job.StdErr = statusUpdate.StdErr
-	job.StdOut = statusUpdate.StdOut
+	job.StdErr = models.CompressedText(statusUpdate.StdErr)
+	job.StdOut = models.CompressedText(statusUpdate.StdOut)
 	job.ExecDuration = statusUpdate.ExecDuration
+	job.CompileDuration = statusUpdate.CompileDuration
+	job.RunDuration = statusUpdate.RunDuration
 	job.MemUsage = statusUpdate.MemUsage
+	// Keep earlier-reported timestamps when a later update omits them
+	if statusUpdate.StartedAt != nil {
+		job.StartedAt = statusUpdate.StartedAt
+	}
+	if statusUpdate.FinishedAt != nil {
+		job.FinishedAt = statusUpdate.FinishedAt
+	}
+	// Updates from workers that don't identify themselves keep the last known placement
+	if statusUpdate.WorkerID != "" {
+		job.WorkerID = statusUpdate.WorkerID
+	}
+	if statusUpdate.Region != "" {
+		job.Region = statusUpdate.Region
+	}
+	replaced := models.Job{JobID: job.JobID, StdOutRef: job.StdOutRef, StdErrRef: job.StdErrRef}
+	s.truncateJobOutput(&job)
+	s.offloadJobOutput(&job)
+
+	// Only write over the status that was read, so a job cancelled, forced or failed by the sweeper
+	// in the meantime keeps that outcome. forced_by is NULL on jobs created before the column existed.
+	result := s.dbService.GetDB().Model(&job).
+		Where("status = ? AND COALESCE(forced_by, '') = ''", previousStatus).
+		Select("*").
+		Updates(&job)
+	if result.Error != nil {
+		s.deleteStoredOutput(job)
+		return fmt.Errorf("failed to update job: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		s.deleteStoredOutput(job)
+		log.WithField("job_id", statusUpdate.ID).Warn("Ignoring status update for job whose status changed while it was applied")
+		return nil
+	}
+	// The job now points at the output offloaded above, if any
+	s.deleteStoredOutput(replaced)
 
-	err = s.dbService.Update(&job)
-	if err != nil {
-		return fmt.Errorf("failed to update job: %w", err)
+	if len(statusUpdate.Artifacts) > 0 {
+		s.recordJobArtifacts(job, statusUpdate.Artifacts)
 	}
 
 	log.WithFields(log.Fields{
@@ -239,65 +816,158 @@ func (s *JobService) updateJobStatus(statusUpdate models.JobStatusUpdate) error
 		"status": statusUpdate.Status,
 	}).Info("Job status updated")
 
-	// Send webhook event if job is completed or failed and webhook service is available
-	if s.webhookService != nil && (status == models.JobStatusCompleted || status == models.JobStatusFailed) {
-		jobResponse, err := s.toWebhookJobResponse(job)
-		if err != nil {
-			log.WithError(err).Error("Failed to convert job to response for webhook")
-		} else {
-			var eventType models.WebhookEventType
-			if status == models.JobStatusCompleted {
-				eventType = models.WebhookEventJobCompleted
-			} else {
-				eventType = models.WebhookEventJobFailed
-			}
-
-			err = s.webhookService.SendWebhookEvent(jobResponse, job.ClerkUserID, eventType)
-			if err != nil {
-				log.WithError(err).WithField("job_id", statusUpdate.ID).Error("Failed to send webhook event")
-			}
-		}
-	}
+	s.waiters.notify(job)
+	s.sendTerminalWebhook(job)
+	s.sendStatusChangedWebhook(job, previousStatus)
+	s.clearEphemeralJobData(job)
 
 	return nil
 }
 
-// toJobResponse converts Job model to JobResponse
+// sendStatusChangedWebhook notifies webhooks subscribed to job.status_changed when a job moved from
+// previousStatus to its current status
+func (s *JobService) sendStatusChangedWebhook(job models.Job, previousStatus models.JobStatus) {
+	if s.webhookService == nil || job.Status == previousStatus {
+		return
+	}
+
+	eventType := models.WebhookEventJobStatusChanged
+	jobResponse, err := s.toWebhookJobResponse(job)
+	if err != nil {
+		log.WithError(err).Error("Failed to convert job to response for webhook")
+		s.webhookService.RecordWebhookEventFailure(job.JobID, job.ClerkUserID, eventType, fmt.Sprintf("failed to build payload: %v", err))
+		return
+	}
+	jobResponse.PreviousStatus = previousStatus
+
+	err = s.webhookService.SendWebhookEvent(jobResponse, job.ClerkUserID, eventType)
+	if err != nil {
+		log.WithError(err).WithField("job_id", job.JobID).Error("Failed to send webhook event")
+	}
+}
+
+// sendTerminalWebhook notifies subscribed webhooks when a job reaches a terminal status
+func (s *JobService) sendTerminalWebhook(job models.Job) {
+	if s.webhookService == nil {
+		return
+	}
+
+	var eventType models.WebhookEventType
+	switch job.Status {
+	case models.JobStatusCompleted:
+		eventType = models.WebhookEventJobCompleted
+	case models.JobStatusFailed:
+		eventType = models.WebhookEventJobFailed
+	case models.JobStatusCancelled:
+		eventType = models.WebhookEventJobCancelled
+	default:
+		return
+	}
+
+	jobResponse, err := s.toWebhookJobResponse(job)
+	if err != nil {
+		log.WithError(err).Error("Failed to convert job to response for webhook")
+		s.webhookService.RecordWebhookEventFailure(job.JobID, job.ClerkUserID, eventType, fmt.Sprintf("failed to build payload: %v", err))
+		return
+	}
+
+	err = s.webhookService.SendWebhookEvent(jobResponse, job.ClerkUserID, eventType)
+	if err != nil {
+		log.WithError(err).WithField("job_id", job.JobID).Error("Failed to send webhook event")
+	}
+}
+
+// toJobResponse converts Job model to JobResponse, reading offloaded output from the output store
 func (s *JobService) toJobResponse(job models.Job) (*models.JobResponse, error) {
-	jobResponse := &models.JobResponse{
-		ID:           job.ID,
-		JobID:        job.JobID,
-		Language:     job.Language,
-		Code:         job.Code,
-		Status:       job.Status,
-		Message:      job.Message,
-		Error:        job.Error,
-		StdErr:       job.StdErr,
-		StdOut:       job.StdOut,
-		ExecDuration: job.ExecDuration,
-		MemUsage:     job.MemUsage,
-		ClerkUserID:  job.ClerkUserID,
-		CreatedAt:    job.CreatedAt,
-		UpdatedAt:    job.UpdatedAt,
+	stdout, stderr, err := s.GetJobOutput(job)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load job output: %w", err)
 	}
 
-	return jobResponse, nil
+	jobResponse := s.toJobListResponse(job)
+	jobResponse.StdOut = stdout
+	jobResponse.StdErr = stderr
+	jobResponse.OutputStored = false
+
+	return &jobResponse, nil
+}
+
+// toJobListResponse converts Job model to JobResponse for listings. Output that was offloaded to
+// the output store is not read; OutputStored tells clients to fetch the job for it.
+func (s *JobService) toJobListResponse(job models.Job) models.JobResponse {
+	return models.JobResponse{
+		ID:                job.ID,
+		JobID:             job.JobID,
+		Language:          job.Language,
+		Name:              job.Name,
+		Description:       job.Description,
+		Tags:              job.Tags,
+		Metadata:          job.Metadata,
+		Args:              job.Args,
+		WorkerVersion:     job.WorkerVersion,
+		Code:              string(job.Code),
+		CodeHash:          job.CodeHash,
+		Status:            job.Status,
+		Priority:          job.Priority,
+		EffectivePriority: job.EffectivePriority,
+		Message:           job.Message,
+		Error:             job.Error,
+		StdErr:            string(job.StdErr),
+		StdOut:            string(job.StdOut),
+		OutputStored:      job.StdOutRef != "" || job.StdErrRef != "",
+		ExecDuration:      job.ExecDuration,
+		CompileDuration:   job.CompileDuration,
+		RunDuration:       job.RunDuration,
+		MemUsage:          job.MemUsage,
+		StartedAt:         job.StartedAt,
+		FinishedAt:        job.FinishedAt,
+		WorkerID:          job.WorkerID,
+		Region:            job.Region,
+		ClerkUserID:       job.ClerkUserID,
+		APIKeyID:          job.APIKeyID,
+		DeadlineAt:        job.DeadlineAt,
+		RunAt:             job.RunAt,
+		Dispatched:        job.DispatchedAt != nil,
+		DispatchedAt:      job.DispatchedAt,
+		PublishedSubject:  job.PublishedSubject,
+		CodePurgedAt:      job.CodePurgedAt,
+		OutputPurgedAt:    job.OutputPurgedAt,
+		Ephemeral:         job.Ephemeral,
+		ParentJobID:       job.ParentJobID,
+		ImportedAt:        job.ImportedAt,
+		CreatedAt:         job.CreatedAt,
+		UpdatedAt:         job.UpdatedAt,
+	}
 }
 
 func (s *JobService) toWebhookJobResponse(job models.Job) (*models.JobWebhookResponse, error) {
+	stdout, stderr, err := s.GetJobOutput(job)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load job output: %w", err)
+	}
+
 	jobWebhookResponse := &models.JobWebhookResponse{
-		JobID:        job.JobID,
-		Language:     job.Language,
-		Code:         job.Code,
-		Status:       job.Status,
-		Message:      job.Message,
-		Error:        job.Error,
-		StdErr:       job.StdErr,
-		StdOut:       job.StdOut,
-		ExecDuration: job.ExecDuration,
-		MemUsage:     job.MemUsage,
-		CreatedAt:    job.CreatedAt,
-		UpdatedAt:    job.UpdatedAt,
+		JobID:           job.JobID,
+		Language:        job.Language,
+		Name:            job.Name,
+		Description:     job.Description,
+		Tags:            job.Tags,
+		Metadata:        job.Metadata,
+		Code:            string(job.Code),
+		Status:          job.Status,
+		Message:         job.Message,
+		Error:           job.Error,
+		StdErr:          stderr,
+		StdOut:          stdout,
+		ExecDuration:    job.ExecDuration,
+		CompileDuration: job.CompileDuration,
+		RunDuration:     job.RunDuration,
+		MemUsage:        job.MemUsage,
+		StartedAt:       job.StartedAt,
+		FinishedAt:      job.FinishedAt,
+		Ephemeral:       job.Ephemeral,
+		CreatedAt:       job.CreatedAt,
+		UpdatedAt:       job.UpdatedAt,
 	}
 
 	return jobWebhookResponse, nil
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"ignis/internal/models"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm/clause"
+)
+
+// jobArtifactLimits bounds the artifacts accepted from workers
+type jobArtifactLimits struct {
+	maxBytes  int64 // Largest artifact accepted
+	maxPerJob int   // Most artifacts recorded for one job
+}
+
+// jobArtifactPrefix is where workers must upload a job's artifacts in the output store
+func jobArtifactPrefix(jobID string) string {
+	return "jobs/" + jobID + "/artifacts/"
+}
+
+// recordJobArtifacts saves the artifacts a worker reported for a job. Reports that exceed
+// JOB_ARTIFACT_MAX_BYTES, point outside the job's artifact prefix or go beyond
+// JOB_ARTIFACTS_PER_JOB are dropped; re-reported filenames are ignored.
+func (s *JobService) recordJobArtifacts(job models.Job, reports []models.JobArtifactReport) {
+	var existing int64
+	if err := s.dbService.GetDB().Model(&models.JobArtifact{}).Where("job_id = ?", job.JobID).Count(&existing).Error; err != nil {
+		log.WithError(err).WithField("job_id", job.JobID).Error("Failed to count job artifacts")
+		return
+	}
+
+	artifacts := make([]models.JobArtifact, 0, len(reports))
+	for _, report := range reports {
+		filename := path.Base(strings.TrimSpace(report.Filename))
+		logger := log.WithFields(log.Fields{"job_id": job.JobID, "filename": report.Filename})
+
+		switch {
+		case filename == "." || filename == "/" || len(filename) > 255:
+			logger.Warn("Dropping job artifact with an invalid filename")
+			continue
+		case report.Size < 0 || report.Size > s.artifactLimits.maxBytes:
+			logger.WithField("size", report.Size).Warn("Dropping job artifact over the size limit")
+			continue
+		case !strings.HasPrefix(report.Ref, jobArtifactPrefix(job.JobID)) || strings.Contains(report.Ref, ".."):
+			logger.Warn("Dropping job artifact stored outside the job's artifact prefix")
+			continue
+		case int(existing)+len(artifacts) >= s.artifactLimits.maxPerJob:
+			logger.Warn("Dropping job artifact over the per-job limit")
+			continue
+		}
+
+		artifacts = append(artifacts, models.JobArtifact{
+			JobID:       job.JobID,
+			Filename:    filename,
+			Size:        report.Size,
+			ContentType: report.ContentType,
+			StorageRef:  report.Ref,
+		})
+	}
+	if len(artifacts) == 0 {
+		return
+	}
+
+	err := s.dbService.GetDB().Clauses(clause.OnConflict{DoNothing: true}).Create(&artifacts).Error
+	if err != nil {
+		log.WithError(err).WithField("job_id", job.JobID).Error("Failed to record job artifacts")
+	}
+}
+
+// GetJobArtifacts lists the artifacts of a job owned by the user
+func (s *JobService) GetJobArtifacts(jobID string, clerkUserID string) ([]models.JobArtifactResponse, error) {
+	var job models.Job
+	if err := s.dbService.FindOne(&job, "job_id = ? AND clerk_user_id = ?", jobID, clerkUserID); err != nil {
+		return nil, ErrJobNotFound
+	}
+
+	var artifacts []models.JobArtifact
+	if err := s.dbService.GetDB().Where("job_id = ?", jobID).Order("filename ASC").Find(&artifacts).Error; err != nil {
+		return nil, fmt.Errorf("failed to get job artifacts: %w", err)
+	}
+
+	responses := make([]models.JobArtifactResponse, 0, len(artifacts))
+	for _, artifact := range artifacts {
+		responses = append(responses, models.JobArtifactResponse{
+			ID:          artifact.ID,
+			JobID:       artifact.JobID,
+			Filename:    artifact.Filename,
+			Size:        artifact.Size,
+			ContentType: artifact.ContentType,
+			CreatedAt:   artifact.CreatedAt,
+		})
+	}
+	return responses, nil
+}
+
+// GetJobArtifactContent returns an artifact of a job owned by the user along with its content
+func (s *JobService) GetJobArtifactContent(jobID string, artifactID uint, clerkUserID string) (*models.JobArtifact, []byte, error) {
+	var job models.Job
+	if err := s.dbService.FindOne(&job, "job_id = ? AND clerk_user_id = ?", jobID, clerkUserID); err != nil {
+		return nil, nil, ErrJobNotFound
+	}
+
+	var artifact models.JobArtifact
+	if err := s.dbService.FindOne(&artifact, "id = ? AND job_id = ?", artifactID, jobID); err != nil {
+		return nil, nil, ErrArtifactNotFound
+	}
+
+	data, err := s.outputStore.Get(s.ctx, artifact.StorageRef)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load artifact: %w", err)
+	}
+	return &artifact, data, nil
+}
+
+// deleteJobArtifacts removes the artifacts matching the condition from the output store and then
+// their rows. Rows whose content fails to delete are kept so a later sweep can retry.
+func (s *JobService) deleteJobArtifacts(query interface{}, args ...interface{}) {
+	var artifacts []models.JobArtifact
+	if err := s.dbService.GetDB().Where(query, args...).Find(&artifacts).Error; err != nil {
+		log.WithError(err).Error("Failed to query job artifacts for deletion")
+		return
+	}
+
+	deleted := make([]uint, 0, len(artifacts))
+	for _, artifact := range artifacts {
+		if err := s.outputStore.Delete(s.ctx, artifact.StorageRef); err != nil {
+			log.WithError(err).WithField("job_id", artifact.JobID).Warn("Failed to delete stored job artifact")
+			continue
+		}
+		deleted = append(deleted, artifact.ID)
+	}
+	if len(deleted) == 0 {
+		return
+	}
+
+	if err := s.dbService.GetDB().Where("id IN ?", deleted).Delete(&models.JobArtifact{}).Error; err != nil {
+		log.WithError(err).Error("Failed to delete job artifacts")
+	}
+}
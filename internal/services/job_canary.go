@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"ignis/internal/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// canaryUserID owns canary jobs so they never match a real user's webhooks or listings
+	canaryUserID = "system:canary"
+	// canaryLanguage and canaryCode make up the trivial job submitted by the deep health check
+	canaryLanguage = "python"
+	canaryCode     = "print('ok')"
+)
+
+// jobWaiters lets callers block until a job reaches a terminal status
+type jobWaiters struct {
+	mutex   sync.Mutex
+	waiters map[string]chan models.Job
+}
+
+// add registers a waiter for a job and returns the channel it will be notified on
+func (w *jobWaiters) add(jobID string) chan models.Job {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	ch := make(chan models.Job, 1)
+	w.waiters[jobID] = ch
+	return ch
+}
+
+// remove unregisters a job's waiter
+func (w *jobWaiters) remove(jobID string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	delete(w.waiters, jobID)
+}
+
+// notify hands a finished or cancelled job to its waiter, if any
+func (w *jobWaiters) notify(job models.Job) {
+	if job.Status != models.JobStatusCompleted && job.Status != models.JobStatusFailed && job.Status != models.JobStatusCancelled {
+		return
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if ch, ok := w.waiters[job.JobID]; ok {
+		ch <- job
+		delete(w.waiters, job.JobID)
+	}
+}
+
+// RunCanary submits a trivial job and waits for a worker to finish it, exercising the
+// database, NATS and the workers end to end. The canary job is deleted afterwards.
+func (s *JobService) RunCanary(ctx context.Context) *models.CanaryResult {
+	start := time.Now()
+	result := &models.CanaryResult{}
+
+	job, err := s.CreateJob(ctx, models.JobCreateRequest{
+		Language: canaryLanguage,
+		Code:     canaryCode,
+		Name:     "health canary",
+		Internal: true,
+	}, canaryUserID)
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to submit canary job: %v", err)
+		result.RoundTripMs = time.Since(start).Milliseconds()
+		return result
+	}
+	result.JobID = job.JobID
+
+	done := s.waiters.add(job.JobID)
+	defer s.waiters.remove(job.JobID)
+	defer s.deleteCanaryJob(job.JobID)
+
+	// The worker may have finished before the waiter was registered
+	var finished *models.Job
+	var current models.Job
+	if err := s.dbService.FindOne(&current, "job_id = ?", job.JobID); err == nil &&
+		(current.Status == models.JobStatusCompleted || current.Status == models.JobStatusFailed) {
+		finished = &current
+	}
+
+	if finished == nil {
+		select {
+		case job := <-done:
+			finished = &job
+		case <-ctx.Done():
+			result.Error = "timed out waiting for a worker to finish the canary job"
+			result.RoundTripMs = time.Since(start).Milliseconds()
+			return result
+		}
+	}
+
+	result.RoundTripMs = time.Since(start).Milliseconds()
+	result.Status = finished.Status
+	result.Success = finished.Status == models.JobStatusCompleted
+	if !result.Success {
+		result.Error = finished.Error
+	}
+	return result
+}
+
+// deleteCanaryJob permanently removes a canary job so it doesn't show up in stats
+func (s *JobService) deleteCanaryJob(jobID string) {
+	err := s.dbService.GetDB().Unscoped().Where("job_id = ? AND clerk_user_id = ?", jobID, canaryUserID).Delete(&models.Job{}).Error
+	if err != nil {
+		log.WithError(err).WithField("job_id", jobID).Warn("Failed to delete canary job")
+	}
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"ignis/internal/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrJobNotCancellable is returned when cancelling a job that has already finished or been cancelled
+var ErrJobNotCancellable = errors.New("job can no longer be cancelled")
+
+// CancelJob cancels one of a user's jobs. Jobs that no worker has started yet, scheduled or
+// still waiting on their NATS subject, are only marked cancelled in the database; the scheduler
+// and priority aging skip them from then on, and updateJobStatus ignores a worker that picks
+// one up anyway. Only running jobs are sent a cancel signal over NATS.
+// It returns ErrJobNotCancellable if the job has already finished.
+func (s *JobService) CancelJob(jobID string, clerkUserID string) (*models.JobResponse, error) {
+	var job models.Job
+	if err := s.dbService.FindOne(&job, "job_id = ? AND clerk_user_id = ?", jobID, clerkUserID); err != nil {
+		return nil, ErrJobNotFound
+	}
+
+	switch job.Status {
+	case models.JobStatusScheduled, models.JobStatusReceived, models.JobStatusRunning:
+	default:
+		return nil, ErrJobNotCancellable
+	}
+
+	wasRunning := job.Status == models.JobStatusRunning
+	finished, err := s.finishJobExternally(&job, map[string]interface{}{"status": models.JobStatusCancelled})
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel job: %w", err)
+	}
+	if !finished {
+		return nil, ErrJobNotCancellable
+	}
+
+	log.WithFields(log.Fields{
+		"job_id":        job.JobID,
+		"clerk_user_id": clerkUserID,
+		"was_running":   wasRunning,
+	}).Info("Job cancelled")
+
+	return s.toJobResponse(job)
+}
+
+// finishJobExternally gives a job the final status in updates without its worker's report, e.g.
+// when it is cancelled or forced. The change only applies if the job is still in the status it
+// was read in, so a worker finishing it concurrently wins and finished is false. Otherwise a
+// running job's worker is told to stop, job is reloaded, and waiters and webhooks are notified as
+// for a worker's report.
+func (s *JobService) finishJobExternally(job *models.Job, updates map[string]interface{}) (finished bool, err error) {
+	result := s.dbService.GetDB().Model(&models.Job{}).
+		Where("id = ? AND status = ?", job.ID, job.Status).
+		Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	previousStatus := job.Status
+	if previousStatus == models.JobStatusRunning {
+		s.publishCancelSignal(*job)
+	}
+
+	if err := s.dbService.FindOne(job, "id = ?", job.ID); err != nil {
+		return true, err
+	}
+	s.waiters.notify(*job)
+	s.sendTerminalWebhook(*job)
+	s.sendStatusChangedWebhook(*job, previousStatus)
+	s.clearEphemeralJobData(*job)
+	return true, nil
+}
+
+// publishCancelSignal tells the worker running a job to stop it, on "cancel.<job_id>".
+// Delivery is best effort: the job is already cancelled, and any later status update is ignored.
+func (s *JobService) publishCancelSignal(job models.Job) {
+	signalData, err := json.Marshal(models.JobCancelSignal{JobID: job.JobID})
+	if err != nil {
+		log.WithError(err).WithField("job_id", job.JobID).Warn("Failed to marshal cancel signal")
+		return
+	}
+
+	if err := s.natsConn.Publish("cancel."+job.JobID, signalData); err != nil {
+		log.WithError(err).WithField("job_id", job.JobID).Warn("Failed to publish cancel signal")
+	}
+}
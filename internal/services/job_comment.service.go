@@ -0,0 +1,105 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"ignis/internal/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// maxCommentsPerJob caps how many comments a single job can accumulate
+const maxCommentsPerJob = 100
+
+// JobCommentService handles business logic for job comments
+type JobCommentService struct {
+	dbService *DBService
+}
+
+// NewJobCommentService creates a new instance of JobCommentService
+func NewJobCommentService(dbService *DBService) *JobCommentService {
+	return &JobCommentService{
+		dbService: dbService,
+	}
+}
+
+// CreateComment adds a comment to a job owned by the user
+func (s *JobCommentService) CreateComment(jobID string, clerkUserID string, req models.JobCommentCreateRequest) (*models.JobCommentResponse, error) {
+	if err := s.verifyJobOwnership(jobID, clerkUserID); err != nil {
+		return nil, err
+	}
+
+	text := strings.TrimSpace(req.Text)
+	if text == "" {
+		return nil, invalidInput("comment text is required")
+	}
+
+	count, err := s.dbService.Count(&models.JobComment{}, "job_id = ?", jobID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxCommentsPerJob {
+		return nil, invalidInput("job has reached the maximum of %d comments", maxCommentsPerJob)
+	}
+
+	comment := models.JobComment{
+		JobID:       jobID,
+		ClerkUserID: clerkUserID,
+		Text:        text,
+	}
+
+	err = s.dbService.Create(&comment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create comment: %w", err)
+	}
+
+	log.WithFields(log.Fields{
+		"comment_id":    comment.ID,
+		"job_id":        jobID,
+		"clerk_user_id": clerkUserID,
+	}).Info("Job comment created")
+
+	return s.toJobCommentResponse(comment), nil
+}
+
+// GetComments retrieves all comments on a job owned by the user, oldest first
+func (s *JobCommentService) GetComments(jobID string, clerkUserID string) ([]models.JobCommentResponse, error) {
+	if err := s.verifyJobOwnership(jobID, clerkUserID); err != nil {
+		return nil, err
+	}
+
+	var comments []models.JobComment
+	err := s.dbService.GetDB().Where("job_id = ?", jobID).Order("created_at ASC").Find(&comments).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch job comments: %w", err)
+	}
+
+	responses := make([]models.JobCommentResponse, 0, len(comments))
+	for _, comment := range comments {
+		responses = append(responses, *s.toJobCommentResponse(comment))
+	}
+
+	return responses, nil
+}
+
+// verifyJobOwnership ensures the job exists and belongs to the user
+func (s *JobCommentService) verifyJobOwnership(jobID string, clerkUserID string) error {
+	var job models.Job
+	err := s.dbService.FindOne(&job, "job_id = ? AND clerk_user_id = ?", jobID, clerkUserID)
+	if err != nil {
+		return ErrJobNotFound
+	}
+	return nil
+}
+
+// toJobCommentResponse converts JobComment model to JobCommentResponse
+func (s *JobCommentService) toJobCommentResponse(comment models.JobComment) *models.JobCommentResponse {
+	return &models.JobCommentResponse{
+		ID:          comment.ID,
+		JobID:       comment.JobID,
+		ClerkUserID: comment.ClerkUserID,
+		Text:        comment.Text,
+		CreatedAt:   comment.CreatedAt,
+	}
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"ignis/internal/config"
+	"ignis/internal/models"
+)
+
+// ErrSubmissionCooldown matches errors returned by CreateJob when the same code was submitted too recently
+var ErrSubmissionCooldown = errors.New("identical job submitted too recently")
+
+// SubmissionCooldownError reports how long the caller must wait before resubmitting the same code
+type SubmissionCooldownError struct {
+	RetryAfter time.Duration
+}
+
+func (e *SubmissionCooldownError) Error() string {
+	return fmt.Sprintf("%s, retry in %ds", ErrSubmissionCooldown.Error(), int(math.Ceil(e.RetryAfter.Seconds())))
+}
+
+// Is lets callers match the error with errors.Is(err, ErrSubmissionCooldown)
+func (e *SubmissionCooldownError) Is(target error) bool {
+	return target == ErrSubmissionCooldown
+}
+
+// submissionCooldown remembers recent (user, language, code) submissions so accidental tight-loop
+// resubmission can be turned away. Markers are kept in memory, so each API server enforces the
+// cooldown for the submissions it received.
+type submissionCooldown struct {
+	window time.Duration // 0 disables the cooldown
+	mu     sync.Mutex
+	seen   map[string]time.Time // marker key -> when the submission was accepted
+}
+
+// newSubmissionCooldown reads JOB_SUBMISSION_COOLDOWN; it is off by default
+func newSubmissionCooldown() *submissionCooldown {
+	return &submissionCooldown{
+		window: config.GetEnvDuration("JOB_SUBMISSION_COOLDOWN", 0),
+		seen:   make(map[string]time.Time),
+	}
+}
+
+// submissionCooldownKey identifies a submission by user, language and a hash of its code
+func submissionCooldownKey(clerkUserID, language, code string) string {
+	return clerkUserID + ":" + language + ":" + models.CodeHash(code)
+}
+
+// claim returns a *SubmissionCooldownError if the same submission was accepted within the window,
+// and otherwise records this one. Checking and recording under one lock means concurrent identical
+// submissions can't both get through.
+func (c *submissionCooldown) claim(key string) error {
+	if c.window <= 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if acceptedAt, ok := c.seen[key]; ok {
+		if wait := c.window - now.Sub(acceptedAt); wait > 0 {
+			return &SubmissionCooldownError{RetryAfter: wait}
+		}
+	}
+	c.seen[key] = now
+	return nil
+}
+
+// release forgets a claimed submission that ended up not being accepted
+func (c *submissionCooldown) release(key string) {
+	if c.window <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.seen, key)
+}
+
+// prune drops expired markers; the job sweeper calls it so claims don't have to scan the map
+func (c *submissionCooldown) prune() {
+	if c.window <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for key, acceptedAt := range c.seen {
+		if now.Sub(acceptedAt) >= c.window {
+			delete(c.seen, key)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"ignis/internal/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// jobETAWindow is how far back completions are averaged for completion estimates
+const jobETAWindow = 24 * time.Hour
+
+// jobETACache holds rolling average execution times of recent completions, per language
+type jobETACache struct {
+	mutex       sync.Mutex
+	ttl         time.Duration
+	concurrency int // Jobs the workers are assumed to run at once when estimating queue wait
+	byLanguage  map[string]time.Duration
+	overall     time.Duration
+	computedAt  time.Time
+}
+
+// averageExecTimes returns the average execution time of jobs completed in the last jobETAWindow,
+// per language and across all languages, cached for JOB_ETA_CACHE_TTL
+func (s *JobService) averageExecTimes() (map[string]time.Duration, time.Duration, error) {
+	s.eta.mutex.Lock()
+	defer s.eta.mutex.Unlock()
+
+	if s.eta.byLanguage != nil && time.Since(s.eta.computedAt) < s.eta.ttl {
+		return s.eta.byLanguage, s.eta.overall, nil
+	}
+
+	var rows []struct {
+		Language string
+		Count    int64
+		AvgMs    float64
+	}
+	err := s.dbService.GetDB().Model(&models.Job{}).
+		Select("language, COUNT(*) AS count, AVG(exec_duration) AS avg_ms").
+		Where("status = ? AND exec_duration > 0 AND updated_at >= ? AND imported_at IS NULL", models.JobStatusCompleted, time.Now().Add(-jobETAWindow)).
+		Group("language").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	byLanguage := make(map[string]time.Duration, len(rows))
+	var totalMs float64
+	var totalCount int64
+	for _, row := range rows {
+		byLanguage[row.Language] = time.Duration(row.AvgMs * float64(time.Millisecond))
+		totalMs += row.AvgMs * float64(row.Count)
+		totalCount += row.Count
+	}
+
+	var overall time.Duration
+	if totalCount > 0 {
+		overall = time.Duration(totalMs / float64(totalCount) * float64(time.Millisecond))
+	}
+
+	s.eta.byLanguage = byLanguage
+	s.eta.overall = overall
+	s.eta.computedAt = time.Now()
+	return byLanguage, overall, nil
+}
+
+// attachCompletionEstimate sets EstimatedCompletionAt on a received or running job. A running
+// job is expected to take its language's average execution time; a received job additionally
+// waits for the jobs queued ahead of it, at the overall average spread over JOB_ETA_CONCURRENCY
+// workers. Jobs are left without an estimate when there's no completion history to go on.
+func (s *JobService) attachCompletionEstimate(job *models.JobResponse) {
+	if job.Status != models.JobStatusReceived && job.Status != models.JobStatusRunning {
+		return
+	}
+
+	byLanguage, overall, err := s.averageExecTimes()
+	if err != nil {
+		log.WithError(err).WithField("job_id", job.JobID).Warn("Failed to load average execution times")
+		return
+	}
+
+	execTime, ok := byLanguage[job.Language]
+	if !ok {
+		execTime = overall
+	}
+	if execTime <= 0 {
+		return
+	}
+
+	now := time.Now()
+	if job.Status == models.JobStatusRunning {
+		// The last status update is when the job started running
+		estimate := job.UpdatedAt.Add(execTime)
+		if estimate.Before(now) {
+			estimate = now
+		}
+		job.EstimatedCompletionAt = &estimate
+		return
+	}
+
+	var ahead int64
+	err = s.dbService.GetDB().Model(&models.Job{}).
+		Where("status = ? AND created_at < ?", models.JobStatusReceived, job.CreatedAt).
+		Count(&ahead).Error
+	if err != nil {
+		log.WithError(err).WithField("job_id", job.JobID).Warn("Failed to count jobs queued ahead")
+		return
+	}
+
+	waitRounds := math.Ceil(float64(ahead) / float64(s.eta.concurrency))
+	estimate := now.Add(time.Duration(waitRounds)*overall + execTime)
+	job.EstimatedCompletionAt = &estimate
+	job.QueuePosition = ahead + 1
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"ignis/internal/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrJobAlreadyFinished is returned when forcing the status of a job that has already finished
+var ErrJobAlreadyFinished = errors.New("job has already finished")
+
+// ForceJobStatus completes or fails any user's unfinished job on an operator's behalf, e.g. one
+// wedged by a lost worker, and sends the webhooks a worker's report would have. Running jobs are
+// also sent a cancel signal, and any later worker update for the job is ignored.
+// It returns ErrJobNotFound or ErrJobAlreadyFinished when there is nothing to force.
+func (s *JobService) ForceJobStatus(jobID string, req models.JobForceStatusRequest, adminUserID string) (*models.AdminJobResponse, error) {
+	var job models.Job
+	if err := s.dbService.FindOne(&job, "job_id = ?", jobID); err != nil {
+		return nil, ErrJobNotFound
+	}
+	if job.Status.IsTerminal() {
+		return nil, ErrJobAlreadyFinished
+	}
+
+	updates := map[string]interface{}{
+		"status":    req.Status,
+		"message":   req.Message,
+		"forced_by": adminUserID,
+	}
+	if req.Status == models.JobStatusFailed {
+		updates["error"] = req.Message
+	}
+
+	previousStatus := job.Status
+	finished, err := s.finishJobExternally(&job, updates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to force job status: %w", err)
+	}
+	if !finished {
+		return nil, ErrJobAlreadyFinished
+	}
+
+	log.WithFields(log.Fields{
+		"job_id":          job.JobID,
+		"clerk_user_id":   job.ClerkUserID,
+		"status":          req.Status,
+		"previous_status": previousStatus,
+		"forced_by":       adminUserID,
+	}).Warn("Job status forced by admin")
+
+	jobResponse, err := s.toJobResponse(job)
+	if err != nil {
+		return nil, err
+	}
+	return &models.AdminJobResponse{
+		JobResponse:     *jobResponse,
+		ExpectedSubject: jobSubject(job),
+		ForcedBy:        job.ForcedBy,
+	}, nil
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"ignis/internal/models"
+
+	"github.com/rs/xid"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// importBatchSize bounds how many imported jobs are inserted per statement
+const importBatchSize = 100
+
+// ImportJobs inserts historical, already finished jobs for a user in a single transaction. The jobs
+// get new IDs but keep their original timestamps, and are never published to the workers or
+// announced to webhooks. The code size and output limits of live jobs apply. Either every record
+// is imported or none are.
+func (s *JobService) ImportJobs(clerkUserID string, records []models.JobImportRecord) ([]models.JobResponse, error) {
+	now := time.Now()
+	jobs := make([]models.Job, 0, len(records))
+
+	for i, record := range records {
+		language, err := models.CanonicalLanguage(record.Language)
+		if err != nil {
+			return nil, invalidInput("job %d: %w", i, err)
+		}
+		if record.Status != models.JobStatusCompleted && record.Status != models.JobStatusFailed {
+			return nil, invalidInput("job %d: status must be completed or failed", i)
+		}
+		if err := s.policy.CheckCodeSize(clerkUserID, strings.TrimSpace(record.Code)); err != nil {
+			return nil, fmt.Errorf("job %d: %w", i, err)
+		}
+		if record.CreatedAt.After(now) {
+			return nil, invalidInput("job %d: created_at must not be in the future", i)
+		}
+
+		updatedAt := record.CreatedAt
+		if record.UpdatedAt != nil {
+			if record.UpdatedAt.Before(record.CreatedAt) || record.UpdatedAt.After(now) {
+				return nil, invalidInput("job %d: updated_at must be between created_at and now", i)
+			}
+			updatedAt = *record.UpdatedAt
+		}
+
+		job := models.Job{
+			JobID:             xid.New().String(),
+			Language:          language,
+			Name:              strings.TrimSpace(record.Name),
+			Description:       strings.TrimSpace(record.Description),
+			Tags:              record.Tags,
+			Metadata:          record.Metadata,
+			Code:              models.CompressedText(strings.TrimSpace(record.Code)),
+			CodeHash:          models.CodeHash(strings.TrimSpace(record.Code)),
+			Status:            record.Status,
+			Priority:          models.JobPriorityNormal,
+			EffectivePriority: models.JobPriorityNormal,
+			Message:           record.Message,
+			Error:             record.Error,
+			StdErr:            models.CompressedText(record.StdErr),
+			StdOut:            models.CompressedText(record.StdOut),
+			ExecDuration:      record.ExecDuration,
+			MemUsage:          record.MemUsage,
+			ClerkUserID:       clerkUserID,
+			ImportedAt:        &now,
+			CreatedAt:         record.CreatedAt,
+			UpdatedAt:         updatedAt,
+		}
+		s.truncateJobOutput(&job)
+		s.offloadJobOutput(&job)
+		jobs = append(jobs, job)
+	}
+
+	err := s.dbService.Transaction(func(tx *gorm.DB) error {
+		return tx.CreateInBatches(&jobs, importBatchSize).Error
+	})
+	if err != nil {
+		for _, job := range jobs {
+			s.deleteStoredOutput(job)
+		}
+		return nil, fmt.Errorf("failed to import jobs: %w", err)
+	}
+
+	log.WithFields(log.Fields{
+		"clerk_user_id": clerkUserID,
+		"jobs":          len(jobs),
+	}).Info("Jobs imported")
+
+	jobResponses := make([]models.JobResponse, 0, len(jobs))
+	for _, job := range jobs {
+		jobResponses = append(jobResponses, s.toJobListResponse(job))
+	}
+
+	return jobResponses, nil
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"ignis/internal/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrJobNotRevalidatable is returned by RevalidateJob for jobs that haven't completed or failed,
+// or whose code was purged and can't be run again
+var ErrJobNotRevalidatable = errors.New("job can't be revalidated")
+
+// RevalidateJob runs a completed or failed job again on the current workers, linked back to it
+// through ParentJobID, and waits until ctx is done for the new job to finish so the two outcomes
+// can be compared. If it hasn't finished by then, the rerun is returned without a comparison.
+func (s *JobService) RevalidateJob(ctx context.Context, jobID string, clerkUserID string) (*models.JobRevalidation, error) {
+	var original models.Job
+	if err := s.dbService.FindOne(&original, "job_id = ? AND clerk_user_id = ?", jobID, clerkUserID); err != nil {
+		return nil, ErrJobNotFound
+	}
+	if original.Status != models.JobStatusCompleted && original.Status != models.JobStatusFailed {
+		return nil, fmt.Errorf("%w: only completed or failed jobs can be revalidated", ErrJobNotRevalidatable)
+	}
+	if original.CodePurgedAt != nil {
+		return nil, fmt.Errorf("%w: its code is no longer stored", ErrJobNotRevalidatable)
+	}
+
+	// Not pinned to the original worker version: the point is to see how the current fleet does
+	rerun, err := s.CreateJob(ctx, models.JobCreateRequest{
+		Language:    original.Language,
+		Code:        string(original.Code),
+		Name:        original.Name,
+		Description: original.Description,
+		Tags:        original.Tags,
+		Metadata:    original.Metadata,
+		Args:        original.Args,
+		Priority:    original.Priority,
+		Ephemeral:   original.Ephemeral,
+		ParentJobID: original.JobID,
+	}, clerkUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	revalidation := &models.JobRevalidation{
+		OriginalJobID:  original.JobID,
+		OriginalStatus: original.Status,
+		Job:            *rerun,
+	}
+
+	done := s.waiters.add(rerun.JobID)
+	defer s.waiters.remove(rerun.JobID)
+
+	// The worker may have finished before the waiter was registered
+	var finished models.Job
+	if err := s.dbService.FindOne(&finished, "job_id = ?", rerun.JobID); err != nil || !finished.Status.IsTerminal() {
+		select {
+		case finished = <-done:
+		case <-ctx.Done():
+			log.WithField("job_id", rerun.JobID).Info("Revalidation rerun still in progress")
+			return revalidation, nil
+		}
+	}
+
+	finishedResponse, err := s.toJobResponse(finished)
+	if err != nil {
+		return nil, err
+	}
+	changed := finished.Status != original.Status
+	revalidation.Job = *finishedResponse
+	revalidation.NewStatus = finished.Status
+	revalidation.OutcomeChanged = &changed
+
+	log.WithFields(log.Fields{
+		"job_id":          original.JobID,
+		"rerun_job_id":    finished.JobID,
+		"original_status": original.Status,
+		"new_status":      finished.Status,
+	}).Info("Job revalidated")
+
+	return revalidation, nil
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"ignis/internal/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// CreateSchedule creates a recurring job schedule, validating its cron expression and the
+// per-user limit from JOB_SCHEDULES_PER_USER
+func (s *JobService) CreateSchedule(req models.JobScheduleCreateRequest, clerkUserID string) (*models.JobScheduleResponse, error) {
+	cron, err := parseCron(req.Cron)
+	if err != nil {
+		return nil, err
+	}
+
+	language, _, err := s.resolveJobLanguage(req.Language)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.maxSchedules > 0 {
+		count, err := s.dbService.Count(&models.JobSchedule{}, "clerk_user_id = ?", clerkUserID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count schedules: %w", err)
+		}
+		if count >= int64(s.maxSchedules) {
+			return nil, invalidInput("schedule limit reached: at most %d schedules per user", s.maxSchedules)
+		}
+	}
+
+	nextRunAt := cron.next(time.Now())
+	if nextRunAt.IsZero() {
+		return nil, invalidInput("cron expression never fires")
+	}
+
+	schedule := models.JobSchedule{
+		Name:        strings.TrimSpace(req.Name),
+		Cron:        strings.Join(strings.Fields(req.Cron), " "),
+		Language:    language,
+		Code:        strings.TrimSpace(req.Code),
+		IsActive:    true,
+		ClerkUserID: clerkUserID,
+		NextRunAt:   &nextRunAt,
+	}
+
+	if err := s.dbService.Create(&schedule); err != nil {
+		return nil, fmt.Errorf("failed to create schedule: %w", err)
+	}
+
+	log.WithFields(log.Fields{
+		"schedule_id":   schedule.ID,
+		"cron":          schedule.Cron,
+		"clerk_user_id": clerkUserID,
+		"next_run_at":   nextRunAt,
+	}).Info("Job schedule created")
+
+	return toJobScheduleResponse(schedule), nil
+}
+
+// GetSchedules retrieves all recurring job schedules for a user
+func (s *JobService) GetSchedules(clerkUserID string) ([]models.JobScheduleResponse, error) {
+	var schedules []models.JobSchedule
+	err := s.dbService.GetDB().Where("clerk_user_id = ?", clerkUserID).Order("created_at DESC").Find(&schedules).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedules: %w", err)
+	}
+
+	responses := make([]models.JobScheduleResponse, 0, len(schedules))
+	for _, schedule := range schedules {
+		responses = append(responses, *toJobScheduleResponse(schedule))
+	}
+	return responses, nil
+}
+
+// GetSchedule retrieves a recurring job schedule by ID for a user
+func (s *JobService) GetSchedule(id uint, clerkUserID string) (*models.JobScheduleResponse, error) {
+	var schedule models.JobSchedule
+	if err := s.dbService.FindOne(&schedule, "id = ? AND clerk_user_id = ?", id, clerkUserID); err != nil {
+		return nil, ErrScheduleNotFound
+	}
+	return toJobScheduleResponse(schedule), nil
+}
+
+// UpdateSchedule updates a recurring job schedule. Changing the cron expression or resuming a
+// paused schedule recomputes its next run; pausing it clears the next run.
+func (s *JobService) UpdateSchedule(id uint, clerkUserID string, req models.JobScheduleUpdateRequest) (*models.JobScheduleResponse, error) {
+	var schedule models.JobSchedule
+	if err := s.dbService.FindOne(&schedule, "id = ? AND clerk_user_id = ?", id, clerkUserID); err != nil {
+		return nil, ErrScheduleNotFound
+	}
+
+	if req.Name != "" {
+		schedule.Name = strings.TrimSpace(req.Name)
+	}
+	if req.Cron != "" {
+		if _, err := parseCron(req.Cron); err != nil {
+			return nil, err
+		}
+		schedule.Cron = strings.Join(strings.Fields(req.Cron), " ")
+	}
+	if req.Language != "" {
+		language, _, err := s.resolveJobLanguage(req.Language)
+		if err != nil {
+			return nil, err
+		}
+		schedule.Language = language
+	}
+	if req.Code != "" {
+		schedule.Code = strings.TrimSpace(req.Code)
+	}
+	if req.IsActive != nil {
+		schedule.IsActive = *req.IsActive
+	}
+
+	schedule.NextRunAt = nil
+	if schedule.IsActive {
+		cron, err := parseCron(schedule.Cron)
+		if err != nil {
+			return nil, err
+		}
+		nextRunAt := cron.next(time.Now())
+		if nextRunAt.IsZero() {
+			return nil, invalidInput("cron expression never fires")
+		}
+		schedule.NextRunAt = &nextRunAt
+	}
+
+	if err := s.dbService.Update(&schedule); err != nil {
+		return nil, fmt.Errorf("failed to update schedule: %w", err)
+	}
+
+	log.WithFields(log.Fields{
+		"schedule_id":   id,
+		"clerk_user_id": clerkUserID,
+	}).Info("Job schedule updated")
+
+	return toJobScheduleResponse(schedule), nil
+}
+
+// DeleteSchedule soft deletes a recurring job schedule; jobs it already created are kept
+func (s *JobService) DeleteSchedule(id uint, clerkUserID string) error {
+	var schedule models.JobSchedule
+	if err := s.dbService.FindOne(&schedule, "id = ? AND clerk_user_id = ?", id, clerkUserID); err != nil {
+		return ErrScheduleNotFound
+	}
+
+	if err := s.dbService.Delete(&schedule, schedule.ID); err != nil {
+		return fmt.Errorf("failed to delete schedule: %w", err)
+	}
+
+	log.WithFields(log.Fields{
+		"schedule_id":   id,
+		"clerk_user_id": clerkUserID,
+	}).Info("Job schedule deleted")
+
+	return nil
+}
+
+// fireDueSchedules creates a job for every active schedule whose next run has arrived.
+// Runs missed while the server was down are skipped rather than replayed.
+func (s *JobService) fireDueSchedules() {
+	var schedules []models.JobSchedule
+	now := time.Now()
+	err := s.dbService.FindWhere(&schedules, "is_active = ? AND next_run_at <= ?", true, now)
+	if err != nil {
+		log.WithError(err).Error("Failed to query due job schedules")
+		return
+	}
+
+	for _, schedule := range schedules {
+		cron, err := parseCron(schedule.Cron)
+		if err != nil {
+			log.WithError(err).WithField("schedule_id", schedule.ID).Error("Job schedule has an invalid cron expression")
+			continue
+		}
+
+		var nextRunAt *time.Time
+		if next := cron.next(now); !next.IsZero() {
+			nextRunAt = &next
+		}
+
+		// Claim this run by advancing next_run_at, so only one instance creates the job
+		result := s.dbService.GetDB().Model(&models.JobSchedule{}).
+			Where("id = ? AND next_run_at = ?", schedule.ID, schedule.NextRunAt).
+			UpdateColumns(map[string]interface{}{
+				"last_run_at": now,
+				"next_run_at": nextRunAt,
+			})
+		if result.Error != nil {
+			log.WithError(result.Error).WithField("schedule_id", schedule.ID).Error("Failed to advance job schedule")
+			continue
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		job, err := s.CreateJob(s.ctx, models.JobCreateRequest{
+			Language: schedule.Language,
+			Code:     schedule.Code,
+			Name:     schedule.Name,
+			Internal: true,
+		}, schedule.ClerkUserID)
+		if err != nil {
+			log.WithError(err).WithField("schedule_id", schedule.ID).Error("Failed to create job from schedule")
+			continue
+		}
+
+		err = s.dbService.GetDB().Model(&models.JobSchedule{}).
+			Where("id = ?", schedule.ID).
+			UpdateColumn("last_job_id", job.JobID).Error
+		if err != nil {
+			log.WithError(err).WithField("schedule_id", schedule.ID).Warn("Failed to record the job created by a schedule")
+		}
+
+		log.WithFields(log.Fields{
+			"schedule_id": schedule.ID,
+			"job_id":      job.JobID,
+			"next_run_at": nextRunAt,
+		}).Info("Job created from schedule")
+	}
+}
+
+// toJobScheduleResponse converts a job schedule to its response format
+func toJobScheduleResponse(schedule models.JobSchedule) *models.JobScheduleResponse {
+	return &models.JobScheduleResponse{
+		ID:          schedule.ID,
+		Name:        schedule.Name,
+		Cron:        schedule.Cron,
+		Language:    schedule.Language,
+		Code:        schedule.Code,
+		IsActive:    schedule.IsActive,
+		ClerkUserID: schedule.ClerkUserID,
+		LastRunAt:   schedule.LastRunAt,
+		LastJobID:   schedule.LastJobID,
+		NextRunAt:   schedule.NextRunAt,
+		CreatedAt:   schedule.CreatedAt,
+		UpdatedAt:   schedule.UpdatedAt,
+	}
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"ignis/internal/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrJobNotScheduled is returned when cancelling a job that has already been released to the workers
+var ErrJobNotScheduled = errors.New("job is not scheduled")
+
+// validateRunAt checks that a requested run time is in the future, within JOB_SCHEDULE_MAX_HORIZON,
+// and before the job's deadline
+func (s *JobService) validateRunAt(runAt *time.Time, deadline *time.Time) error {
+	if runAt == nil {
+		return nil
+	}
+
+	now := time.Now()
+	if !runAt.After(now) {
+		return invalidInput("run_at must be in the future")
+	}
+	if s.maxRunAhead > 0 && runAt.After(now.Add(s.maxRunAhead)) {
+		return invalidInput("run_at must be within %s", s.maxRunAhead)
+	}
+	if deadline != nil && !deadline.After(*runAt) {
+		return invalidInput("deadline must be after run_at")
+	}
+	return nil
+}
+
+// runJobScheduler periodically releases scheduled jobs whose run time has arrived and
+// creates jobs from recurring schedules that are due
+func (s *JobService) runJobScheduler(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.dispatchScheduledJobs()
+			s.fireDueSchedules()
+		}
+	}
+}
+
+// dispatchScheduledJobs moves due scheduled jobs to received and publishes them to NATS
+func (s *JobService) dispatchScheduledJobs() {
+	var jobs []models.Job
+	err := s.dbService.GetDB().
+		Where("status = ? AND run_at <= ?", models.JobStatusScheduled, time.Now()).
+		Order("run_at ASC").
+		Find(&jobs).Error
+	if err != nil {
+		log.WithError(err).Error("Failed to query due scheduled jobs")
+		return
+	}
+
+	for _, job := range jobs {
+		// Only release jobs that weren't cancelled or released by another instance in the meantime
+		result := s.dbService.GetDB().Model(&models.Job{}).
+			Where("id = ? AND status = ?", job.ID, models.JobStatusScheduled).
+			Update("status", models.JobStatusReceived)
+		if result.Error != nil {
+			log.WithError(result.Error).WithField("job_id", job.JobID).Error("Failed to release scheduled job")
+			continue
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		job.Status = models.JobStatusReceived
+		if err := s.publishJob(&job); err != nil {
+			log.WithError(err).WithField("job_id", job.JobID).Error("Failed to publish scheduled job")
+			// Put the job back so the next tick retries it instead of leaving it in received unpublished
+			err := s.dbService.GetDB().Model(&models.Job{}).
+				Where("id = ? AND status = ?", job.ID, models.JobStatusReceived).
+				Update("status", models.JobStatusScheduled).Error
+			if err != nil {
+				log.WithError(err).WithField("job_id", job.JobID).Error("Failed to return unpublished job to scheduled")
+			}
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			"job_id":   job.JobID,
+			"language": job.Language,
+			"run_at":   job.RunAt,
+		}).Info("Scheduled job published to NATS")
+
+		s.sendStatusChangedWebhook(job, models.JobStatusScheduled)
+	}
+}
+
+// GetScheduledJobs retrieves a user's jobs that are still waiting for their run time, soonest first
+func (s *JobService) GetScheduledJobs(clerkUserID string) ([]models.JobResponse, error) {
+	var jobs []models.Job
+	err := s.dbService.GetDB().
+		Where("clerk_user_id = ? AND status = ?", clerkUserID, models.JobStatusScheduled).
+		Order("run_at ASC").
+		Find(&jobs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduled jobs: %w", err)
+	}
+
+	jobResponses := make([]models.JobResponse, 0, len(jobs))
+	for _, job := range jobs {
+		jobResponses = append(jobResponses, s.toJobListResponse(job))
+	}
+
+	return jobResponses, nil
+}
+
+// CancelScheduledJob cancels a user's scheduled job before it is sent to the workers.
+// It returns ErrJobNotScheduled if the job has already been released.
+func (s *JobService) CancelScheduledJob(jobID string, clerkUserID string) (*models.JobResponse, error) {
+	var job models.Job
+	if err := s.dbService.FindOne(&job, "job_id = ? AND clerk_user_id = ?", jobID, clerkUserID); err != nil {
+		return nil, ErrJobNotFound
+	}
+	if job.Status != models.JobStatusScheduled {
+		return nil, ErrJobNotScheduled
+	}
+
+	return s.CancelJob(jobID, clerkUserID)
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"ignis/internal/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrJobCapacityReached is returned by CreateJob when MAX_IN_FLIGHT_JOBS jobs are already waiting or running,
+// or MAX_RUNNING_JOBS are running
+var ErrJobCapacityReached = errors.New("the system is at capacity, please retry shortly")
+
+// inFlightCache holds the last in-flight job counts so frequent scrapes don't hit the database
+type inFlightCache struct {
+	mutex  sync.Mutex
+	ttl    time.Duration
+	counts *models.JobInFlightCounts
+}
+
+// languageStatsCache holds the language leaderboard per time window
+type languageStatsCache struct {
+	mutex   sync.Mutex
+	ttl     time.Duration
+	entries map[int]languageStatsEntry // keyed by window in days
+}
+
+type languageStatsEntry struct {
+	usage      []models.LanguageUsage
+	computedAt time.Time
+}
+
+// statsOverviewCache holds the system-wide throughput overview per time window
+type statsOverviewCache struct {
+	mutex   sync.Mutex
+	ttl     time.Duration
+	entries map[int]*models.JobStatsOverview // keyed by window in hours
+}
+
+// GetInFlightCounts returns the number of received and running jobs, cached for JOB_STATS_CACHE_TTL
+func (s *JobService) GetInFlightCounts() (*models.JobInFlightCounts, error) {
+	s.inFlight.mutex.Lock()
+	defer s.inFlight.mutex.Unlock()
+
+	// Callers get a copy, since responses localize timestamps in place
+	if s.inFlight.counts != nil && time.Since(s.inFlight.counts.ComputedAt) < s.inFlight.ttl {
+		copied := *s.inFlight.counts
+		return &copied, nil
+	}
+
+	var rows []struct {
+		Status models.JobStatus
+		Count  int64
+	}
+	err := s.dbService.GetDB().Model(&models.Job{}).
+		Select("status, COUNT(*) AS count").
+		Where("status IN ?", []models.JobStatus{models.JobStatusReceived, models.JobStatusRunning}).
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count in-flight jobs: %w", err)
+	}
+
+	counts := &models.JobInFlightCounts{ComputedAt: time.Now()}
+	for _, row := range rows {
+		switch row.Status {
+		case models.JobStatusReceived:
+			counts.Received = row.Count
+		case models.JobStatusRunning:
+			counts.Running = row.Count
+		}
+	}
+
+	s.inFlight.counts = counts
+	copied := *counts
+	return &copied, nil
+}
+
+// QueueDepth returns how many jobs are waiting for a worker. Jobs are published over core NATS,
+// which keeps no backlog to query, so this counts received jobs in the database instead (cached
+// with the in-flight counts for JOB_STATS_CACHE_TTL).
+func (s *JobService) QueueDepth() (int64, error) {
+	counts, err := s.GetInFlightCounts()
+	if err != nil {
+		return 0, err
+	}
+	return counts.Received, nil
+}
+
+// CountByLanguage returns how many jobs a user submitted per language, optionally only those created since a time
+func (s *JobService) CountByLanguage(clerkUserID string, since *time.Time) (map[string]int64, error) {
+	query := s.dbService.GetDB().Model(&models.Job{}).Where("clerk_user_id = ?", clerkUserID)
+	if since != nil {
+		query = query.Where("created_at >= ?", *since)
+	}
+
+	var rows []struct {
+		Language string
+		Count    int64
+	}
+	err := query.Select("language, COUNT(*) AS count").Group("language").Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count jobs by language: %w", err)
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Language] = row.Count
+	}
+	return counts, nil
+}
+
+// GetLanguageLeaderboard returns job counts per language across all users over the last
+// windowDays days, most used first. Only aggregates are returned, never per-user data. Imported
+// jobs are left out, as they are on the stats overview and in ETAs.
+// Results are cached for LANGUAGE_STATS_CACHE_TTL.
+func (s *JobService) GetLanguageLeaderboard(windowDays int) ([]models.LanguageUsage, error) {
+	s.languageStats.mutex.Lock()
+	defer s.languageStats.mutex.Unlock()
+
+	if entry, ok := s.languageStats.entries[windowDays]; ok && time.Since(entry.computedAt) < s.languageStats.ttl {
+		return entry.usage, nil
+	}
+
+	usage := make([]models.LanguageUsage, 0)
+	err := s.dbService.GetDB().Model(&models.Job{}).
+		Select("language, COUNT(*) AS count").
+		Where("created_at >= ? AND imported_at IS NULL", time.Now().AddDate(0, 0, -windowDays)).
+		Group("language").
+		Order("count DESC, language ASC").
+		Scan(&usage).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute language usage: %w", err)
+	}
+
+	s.languageStats.entries[windowDays] = languageStatsEntry{usage: usage, computedAt: time.Now()}
+	return usage, nil
+}
+
+// GetStatsOverview returns how many jobs finished across all users over the last windowHours hours,
+// their failure rate and average execution time. Only system-wide aggregates are computed, so the
+// result is safe to serve publicly. Results are cached for STATS_OVERVIEW_CACHE_TTL.
+func (s *JobService) GetStatsOverview(windowHours int) (*models.JobStatsOverview, error) {
+	s.statsOverview.mutex.Lock()
+	defer s.statsOverview.mutex.Unlock()
+
+	// Callers get a copy, since responses localize timestamps in place
+	if overview, ok := s.statsOverview.entries[windowHours]; ok && time.Since(overview.ComputedAt) < s.statsOverview.ttl {
+		copied := *overview
+		return &copied, nil
+	}
+
+	var row struct {
+		Completed       int64
+		Failed          int64
+		AvgExecDuration float64
+	}
+	err := s.dbService.GetDB().Model(&models.Job{}).
+		Select("COUNT(*) FILTER (WHERE status = ?) AS completed, COUNT(*) FILTER (WHERE status = ?) AS failed, "+
+			"COALESCE(AVG(exec_duration) FILTER (WHERE status = ?), 0) AS avg_exec_duration",
+			models.JobStatusCompleted, models.JobStatusFailed, models.JobStatusCompleted).
+		Where("status IN ? AND updated_at >= ? AND imported_at IS NULL", []models.JobStatus{models.JobStatusCompleted, models.JobStatusFailed},
+			time.Now().Add(-time.Duration(windowHours)*time.Hour)).
+		Scan(&row).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute stats overview: %w", err)
+	}
+
+	overview := &models.JobStatsOverview{
+		WindowHours:     windowHours,
+		Completed:       row.Completed,
+		Failed:          row.Failed,
+		AvgExecDuration: row.AvgExecDuration,
+		ComputedAt:      time.Now(),
+	}
+	if finished := row.Completed + row.Failed; finished > 0 {
+		overview.FailureRate = float64(row.Failed) / float64(finished)
+	}
+
+	s.statsOverview.entries[windowHours] = overview
+	copied := *overview
+	return &copied, nil
+}
+
+// checkCapacity returns ErrJobCapacityReached when the number of received and running jobs has reached
+// MAX_IN_FLIGHT_JOBS, or the number of running jobs alone has reached MAX_RUNNING_JOBS. The counts come
+// from the in-flight cache, so either ceiling may be overshot by the jobs submitted within one
+// JOB_STATS_CACHE_TTL.
+func (s *JobService) checkCapacity() error {
+	if s.maxInFlight <= 0 && s.maxRunning <= 0 {
+		return nil
+	}
+
+	counts, err := s.GetInFlightCounts()
+	if err != nil {
+		// Don't turn a counting failure into an outage; the workers still pace themselves
+		log.WithError(err).Warn("Failed to check job capacity")
+		return nil
+	}
+	if s.maxInFlight > 0 && counts.Received+counts.Running >= int64(s.maxInFlight) {
+		return ErrJobCapacityReached
+	}
+	if s.maxRunning > 0 && counts.Running >= int64(s.maxRunning) {
+		return ErrJobCapacityReached
+	}
+	return nil
+}
+
+// CapacityRetryAfter is how long clients turned away by the in-flight or running cap should wait, i.e. until
+// the cached count is next refreshed
+func (s *JobService) CapacityRetryAfter() time.Duration {
+	if s.inFlight.ttl < time.Second {
+		return time.Second
+	}
+	return s.inFlight.ttl
+}
@@ -0,0 +1,324 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"ignis/internal/config"
+	"ignis/internal/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// deadlineExceededError is recorded on jobs that did not start before their deadline
+const deadlineExceededError = "deadline exceeded before execution"
+
+// stuckJobError is recorded on running jobs that stopped reporting progress
+const stuckJobError = "job stopped responding while running"
+
+// stuckThresholds controls how long a job may stay running without a status update before the
+// sweeper fails it. Slow-compiling languages can be given more time than the default.
+type stuckThresholds struct {
+	byLanguage map[string]time.Duration
+	fallback   time.Duration // 0 disables stuck-job detection for languages without their own threshold
+}
+
+// loadStuckThresholds reads JOB_STUCK_AFTER and the per-language JOB_STUCK_AFTER_BY_LANGUAGE overrides
+func loadStuckThresholds() stuckThresholds {
+	thresholds := stuckThresholds{
+		byLanguage: make(map[string]time.Duration),
+		fallback:   config.GetEnvDuration("JOB_STUCK_AFTER", 10*time.Minute),
+	}
+
+	for language, raw := range config.GetEnvMap("JOB_STUCK_AFTER_BY_LANGUAGE") {
+		threshold, err := time.ParseDuration(raw)
+		if err != nil || threshold < 0 {
+			log.WithField("language", language).Warn("Ignoring invalid stuck job threshold")
+			continue
+		}
+		if canonical, err := models.CanonicalLanguage(language); err == nil {
+			language = canonical
+		}
+		thresholds.byLanguage[strings.ToLower(language)] = threshold
+	}
+
+	return thresholds
+}
+
+// forLanguage returns the stuck threshold for a language; 0 means jobs in it are never failed as stuck
+func (t stuckThresholds) forLanguage(language string) time.Duration {
+	if threshold, ok := t.byLanguage[strings.ToLower(language)]; ok {
+		return threshold
+	}
+	return t.fallback
+}
+
+// shortest returns the smallest enabled threshold, or 0 when detection is disabled for every language
+func (t stuckThresholds) shortest() time.Duration {
+	shortest := t.fallback
+	for _, threshold := range t.byLanguage {
+		if threshold > 0 && (shortest == 0 || threshold < shortest) {
+			shortest = threshold
+		}
+	}
+	return shortest
+}
+
+// runJobSweeper periodically applies time-based job transitions
+func (s *JobService) runJobSweeper(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.failExpiredJobs()
+			s.failStuckJobs()
+			s.ageWaitingJobs()
+			s.purgeExpiredJobData()
+			s.cooldown.prune()
+		}
+	}
+}
+
+// failExpiredJobs fails jobs still waiting for a worker after their deadline passed
+func (s *JobService) failExpiredJobs() {
+	var jobs []models.Job
+	err := s.dbService.FindWhere(&jobs, "status = ? AND deadline_at IS NOT NULL AND deadline_at < ?",
+		models.JobStatusReceived, time.Now())
+	if err != nil {
+		log.WithError(err).Error("Failed to query jobs past their deadline")
+		return
+	}
+
+	for _, job := range jobs {
+		// Only transition jobs a worker hasn't claimed in the meantime
+		result := s.dbService.GetDB().Model(&models.Job{}).
+			Where("id = ? AND status = ?", job.ID, models.JobStatusReceived).
+			Updates(map[string]interface{}{
+				"status": models.JobStatusFailed,
+				"error":  deadlineExceededError,
+			})
+		if result.Error != nil {
+			log.WithError(result.Error).WithField("job_id", job.JobID).Error("Failed to fail job past its deadline")
+			continue
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		job.Status = models.JobStatusFailed
+		job.Error = deadlineExceededError
+
+		log.WithFields(log.Fields{
+			"job_id":      job.JobID,
+			"deadline_at": job.DeadlineAt,
+		}).Warn("Job failed: deadline exceeded before execution")
+
+		s.sendTerminalWebhook(job)
+		s.sendStatusChangedWebhook(job, models.JobStatusReceived)
+		s.clearEphemeralJobData(job)
+	}
+}
+
+// failStuckJobs fails running jobs that haven't had a status update for longer than their
+// language's stuck threshold, so a hung worker doesn't leave them running forever
+func (s *JobService) failStuckJobs() {
+	shortest := s.stuckAfter.shortest()
+	if shortest <= 0 {
+		return
+	}
+
+	var jobs []models.Job
+	now := time.Now()
+	err := s.dbService.FindWhere(&jobs, "status = ? AND updated_at < ?", models.JobStatusRunning, now.Add(-shortest))
+	if err != nil {
+		log.WithError(err).Error("Failed to query stuck jobs")
+		return
+	}
+
+	for _, job := range jobs {
+		threshold := s.stuckAfter.forLanguage(job.Language)
+		if threshold <= 0 || now.Sub(job.UpdatedAt) < threshold {
+			continue
+		}
+
+		// Only fail jobs that haven't reported progress or finished in the meantime
+		result := s.dbService.GetDB().Model(&models.Job{}).
+			Where("id = ? AND status = ? AND updated_at = ?", job.ID, models.JobStatusRunning, job.UpdatedAt).
+			Updates(map[string]interface{}{
+				"status": models.JobStatusFailed,
+				"error":  stuckJobError,
+			})
+		if result.Error != nil {
+			log.WithError(result.Error).WithField("job_id", job.JobID).Error("Failed to fail stuck job")
+			continue
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		job.Status = models.JobStatusFailed
+		job.Error = stuckJobError
+
+		log.WithFields(log.Fields{
+			"job_id":    job.JobID,
+			"language":  job.Language,
+			"threshold": threshold,
+		}).Warn("Job failed: no status update while running")
+
+		s.waiters.notify(job)
+		s.sendTerminalWebhook(job)
+		s.sendStatusChangedWebhook(job, models.JobStatusRunning)
+		s.clearEphemeralJobData(job)
+	}
+}
+
+// ageWaitingJobs raises the effective priority of jobs that have waited in received for longer
+// than JOB_PRIORITY_AGING_AFTER since they were published or last aged, and re-publishes them on
+// the higher priority's subject so sustained high-priority load can't starve them. The original
+// message stays queued; whichever worker reports the job first claims it and updateJobStatus
+// ignores the other.
+func (s *JobService) ageWaitingJobs() {
+	if s.priorityAging <= 0 {
+		return
+	}
+
+	var jobs []models.Job
+	err := s.dbService.FindWhere(&jobs, "status = ? AND effective_priority <> ? AND COALESCE(aged_at, dispatched_at, created_at) < ?",
+		models.JobStatusReceived, models.JobPriorityHigh, time.Now().Add(-s.priorityAging))
+	if err != nil {
+		log.WithError(err).Error("Failed to query jobs waiting for aging")
+		return
+	}
+
+	for _, job := range jobs {
+		raised := job.EffectivePriority.Raised()
+		now := time.Now()
+
+		// Only age jobs a worker hasn't claimed or another instance hasn't aged in the meantime
+		result := s.dbService.GetDB().Model(&models.Job{}).
+			Where("id = ? AND status = ? AND effective_priority = ?", job.ID, models.JobStatusReceived, job.EffectivePriority).
+			UpdateColumns(map[string]interface{}{
+				"effective_priority": raised,
+				"aged_at":            now,
+			})
+		if result.Error != nil {
+			log.WithError(result.Error).WithField("job_id", job.JobID).Error("Failed to age waiting job")
+			continue
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		job.EffectivePriority = raised
+		job.AgedAt = &now
+		if err := s.publishJob(&job); err != nil {
+			log.WithError(err).WithField("job_id", job.JobID).Error("Failed to re-publish aged job")
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			"job_id":             job.JobID,
+			"priority":           job.Priority,
+			"effective_priority": raised,
+		}).Info("Raised priority of long-waiting job")
+	}
+}
+
+// jobRetention controls how long finished jobs keep their code and their output; zero keeps them forever
+type jobRetention struct {
+	code   time.Duration
+	output time.Duration
+}
+
+// clearEphemeralJobData clears the code, output and artifacts of an ephemeral job once it has
+// finished and been handed to its waiter and webhooks. The purge timestamps tell clients why the fields are empty.
+func (s *JobService) clearEphemeralJobData(job models.Job) {
+	if !job.Ephemeral || !job.Status.IsTerminal() {
+		return
+	}
+
+	s.deleteStoredOutput(job)
+	s.deleteJobArtifacts("job_id = ?", job.JobID)
+
+	now := time.Now()
+	err := s.dbService.GetDB().Model(&models.Job{}).
+		Where("id = ?", job.ID).
+		UpdateColumns(map[string]interface{}{
+			"code":             "",
+			"std_out":          "",
+			"std_err":          "",
+			"std_out_ref":      "",
+			"std_err_ref":      "",
+			"error":            "",
+			"code_purged_at":   now,
+			"output_purged_at": now,
+		}).Error
+	if err != nil {
+		log.WithError(err).WithField("job_id", job.JobID).Error("Failed to clear ephemeral job data")
+	}
+}
+
+// purgeExpiredJobData clears the code and output of finished jobs once their retention windows pass;
+// artifacts count as output.
+// Job rows are kept; the purge timestamps tell clients why the fields are empty.
+func (s *JobService) purgeExpiredJobData() {
+	finished := []models.JobStatus{models.JobStatusCompleted, models.JobStatusFailed, models.JobStatusCancelled}
+	now := time.Now()
+
+	// UpdateColumns leaves updated_at alone, so it keeps marking when the job finished
+	if s.retention.output > 0 {
+		cutoff := now.Add(-s.retention.output)
+
+		// Offloaded outputs live outside the jobs table, so remove them from the output store first
+		var offloaded []models.Job
+		err := s.dbService.GetDB().Select("id, job_id, std_out_ref, std_err_ref").
+			Where("status IN ? AND output_purged_at IS NULL AND updated_at < ? AND (std_out_ref <> '' OR std_err_ref <> '')", finished, cutoff).
+			Find(&offloaded).Error
+		if err != nil {
+			log.WithError(err).Error("Failed to query expired offloaded job output")
+		}
+		for _, job := range offloaded {
+			s.deleteStoredOutput(job)
+		}
+		s.deleteJobArtifacts("job_id IN (?)", s.dbService.GetDB().Model(&models.Job{}).
+			Select("job_id").Where("status IN ? AND updated_at < ?", finished, cutoff))
+
+		result := s.dbService.GetDB().Model(&models.Job{}).
+			Where("status IN ? AND output_purged_at IS NULL AND updated_at < ?", finished, cutoff).
+			UpdateColumns(map[string]interface{}{
+				"std_out":          "",
+				"std_err":          "",
+				"std_out_ref":      "",
+				"std_err_ref":      "",
+				"error":            "",
+				"output_purged_at": now,
+			})
+		if result.Error != nil {
+			log.WithError(result.Error).Error("Failed to purge expired job output")
+		} else if result.RowsAffected > 0 {
+			log.WithField("jobs", result.RowsAffected).Info("Purged expired job output")
+		}
+	}
+
+	if s.retention.code > 0 {
+		result := s.dbService.GetDB().Model(&models.Job{}).
+			Where("status IN ? AND code_purged_at IS NULL AND updated_at < ?", finished, now.Add(-s.retention.code)).
+			UpdateColumns(map[string]interface{}{
+				"code":           "",
+				"code_purged_at": now,
+			})
+		if result.Error != nil {
+			log.WithError(result.Error).Error("Failed to purge expired job code")
+		} else if result.RowsAffected > 0 {
+			log.WithField("jobs", result.RowsAffected).Info("Purged expired job code")
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"ignis/internal/config"
+	"ignis/internal/models"
+
+	"github.com/rs/xid"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OutputStore keeps large job outputs outside the jobs table. Jobs reference stored outputs by key.
+type OutputStore interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// newOutputStore returns the output store selected by OUTPUT_STORE: "db" (default) or "s3".
+// A misconfigured S3 store falls back to the database so outputs are never lost.
+func newOutputStore(dbService *DBService) OutputStore {
+	switch strings.ToLower(config.GetEnv("OUTPUT_STORE", "db")) {
+	case "s3":
+		store, err := newS3OutputStore()
+		if err != nil {
+			log.WithError(err).Error("Failed to configure S3 output store, falling back to the database")
+			return &dbOutputStore{dbService: dbService}
+		}
+		return store
+	default:
+		return &dbOutputStore{dbService: dbService}
+	}
+}
+
+// dbOutputStore keeps outputs in the job_outputs table, away from the frequently scanned jobs table
+type dbOutputStore struct {
+	dbService *DBService
+}
+
+// Put stores data under key, replacing any previous value
+func (s *dbOutputStore) Put(ctx context.Context, key string, data []byte) error {
+	output := models.JobOutput{Key: key, Data: string(data)}
+	err := s.dbService.GetDB().WithContext(ctx).
+		Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(&output).Error
+	if err != nil {
+		return fmt.Errorf("failed to store output: %w", err)
+	}
+	return nil
+}
+
+// Get loads the data stored under key
+func (s *dbOutputStore) Get(ctx context.Context, key string) ([]byte, error) {
+	var output models.JobOutput
+	err := s.dbService.GetDB().WithContext(ctx).Where("key = ?", key).First(&output).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("output %s not found", key)
+		}
+		return nil, fmt.Errorf("failed to load output: %w", err)
+	}
+	return []byte(output.Data), nil
+}
+
+// Delete removes the data stored under key
+func (s *dbOutputStore) Delete(ctx context.Context, key string) error {
+	err := s.dbService.GetDB().WithContext(ctx).Where("key = ?", key).Delete(&models.JobOutput{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to delete output: %w", err)
+	}
+	return nil
+}
+
+// jobOutputKey names a new stored output of one stream of a job. Every write gets its own key, so
+// output offloaded for a status update that is then rejected never replaces the output in use.
+func jobOutputKey(jobID string, stream string) string {
+	return "jobs/" + jobID + "/" + stream + "/" + xid.New().String()
+}
+
+// jobOutputTruncatedNote is appended to stdout and stderr cut off at the job's output cap
+const jobOutputTruncatedNote = "\n[output truncated]"
+
+// truncateJobOutput cuts stdout and stderr down to the output cap of the job's language, falling
+// back to JOB_OUTPUT_MAX_BYTES for languages that don't set one
+func (s *JobService) truncateJobOutput(job *models.Job) {
+	limit := models.LanguageMaxOutputBytes(job.Language)
+	if limit <= 0 {
+		limit = s.maxOutputBytes
+	}
+	if limit <= 0 {
+		return
+	}
+
+	if len(job.StdOut) > limit {
+		job.StdOut = models.CompressedText(strings.ToValidUTF8(string(job.StdOut[:limit]), "") + jobOutputTruncatedNote)
+		log.WithFields(log.Fields{"job_id": job.JobID, "limit": limit}).Info("Truncated job stdout")
+	}
+	if len(job.StdErr) > limit {
+		job.StdErr = models.CompressedText(strings.ToValidUTF8(string(job.StdErr[:limit]), "") + jobOutputTruncatedNote)
+		log.WithFields(log.Fields{"job_id": job.JobID, "limit": limit}).Info("Truncated job stderr")
+	}
+}
+
+// offloadJobOutput moves stdout and stderr larger than OUTPUT_STORE_THRESHOLD into the output
+// store, leaving only a reference on the job. Outputs that fail to store stay inline.
+func (s *JobService) offloadJobOutput(job *models.Job) {
+	// The outputs on the job are the latest, so earlier references no longer apply
+	job.StdOutRef, job.StdErrRef = "", ""
+
+	if s.outputThreshold <= 0 {
+		return
+	}
+
+	if len(job.StdOut) > s.outputThreshold {
+		key := jobOutputKey(job.JobID, "stdout")
+		if err := s.outputStore.Put(s.ctx, key, []byte(job.StdOut)); err != nil {
+			log.WithError(err).WithField("job_id", job.JobID).Warn("Failed to offload stdout, keeping it inline")
+		} else {
+			job.StdOut = ""
+			job.StdOutRef = key
+		}
+	}
+
+	if len(job.StdErr) > s.outputThreshold {
+		key := jobOutputKey(job.JobID, "stderr")
+		if err := s.outputStore.Put(s.ctx, key, []byte(job.StdErr)); err != nil {
+			log.WithError(err).WithField("job_id", job.JobID).Warn("Failed to offload stderr, keeping it inline")
+		} else {
+			job.StdErr = ""
+			job.StdErrRef = key
+		}
+	}
+}
+
+// GetJobOutput returns a job's stdout and stderr, reading them from the output store when they
+// were offloaded
+func (s *JobService) GetJobOutput(job models.Job) (string, string, error) {
+	stdout, stderr := string(job.StdOut), string(job.StdErr)
+
+	if job.StdOutRef != "" {
+		data, err := s.outputStore.Get(s.ctx, job.StdOutRef)
+		if err != nil {
+			return "", "", err
+		}
+		stdout = string(data)
+	}
+
+	if job.StdErrRef != "" {
+		data, err := s.outputStore.Get(s.ctx, job.StdErrRef)
+		if err != nil {
+			return "", "", err
+		}
+		stderr = string(data)
+	}
+
+	return stdout, stderr, nil
+}
+
+// deleteStoredOutput removes a job's offloaded outputs, logging rather than failing
+func (s *JobService) deleteStoredOutput(job models.Job) {
+	for _, key := range []string{job.StdOutRef, job.StdErrRef} {
+		if key == "" {
+			continue
+		}
+		if err := s.outputStore.Delete(s.ctx, key); err != nil {
+			log.WithError(err).WithField("job_id", job.JobID).Warn("Failed to delete stored job output")
+		}
+	}
+}
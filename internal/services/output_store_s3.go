@@ -0,0 +1,177 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"ignis/internal/config"
+)
+
+// s3OutputStore keeps outputs in an S3-compatible bucket, addressed path-style so it also works
+// with MinIO and other self-hosted object stores. Requests are signed with AWS Signature V4.
+type s3OutputStore struct {
+	endpoint   *url.URL
+	bucket     string
+	region     string
+	accessKey  string
+	secretKey  string
+	httpClient *http.Client
+}
+
+// newS3OutputStore configures the S3 store from the OUTPUT_STORE_S3_* environment variables
+func newS3OutputStore() (*s3OutputStore, error) {
+	rawEndpoint := config.GetEnv("OUTPUT_STORE_S3_ENDPOINT", "")
+	bucket := config.GetEnv("OUTPUT_STORE_S3_BUCKET", "")
+	accessKey := config.GetEnv("OUTPUT_STORE_S3_ACCESS_KEY", "")
+	secretKey := config.GetEnv("OUTPUT_STORE_S3_SECRET_KEY", "")
+	if rawEndpoint == "" || bucket == "" || accessKey == "" || secretKey == "" {
+		return nil, fmt.Errorf("OUTPUT_STORE_S3_ENDPOINT, OUTPUT_STORE_S3_BUCKET, OUTPUT_STORE_S3_ACCESS_KEY and OUTPUT_STORE_S3_SECRET_KEY are required")
+	}
+
+	endpoint, err := url.Parse(strings.TrimRight(rawEndpoint, "/"))
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid OUTPUT_STORE_S3_ENDPOINT %q", rawEndpoint)
+	}
+
+	return &s3OutputStore{
+		endpoint:   endpoint,
+		bucket:     bucket,
+		region:     config.GetEnv("OUTPUT_STORE_S3_REGION", "us-east-1"),
+		accessKey:  accessKey,
+		secretKey:  secretKey,
+		httpClient: &http.Client{Timeout: config.GetEnvDuration("OUTPUT_STORE_S3_TIMEOUT", 30*time.Second)},
+	}, nil
+}
+
+// Put uploads data under key, replacing any previous object
+func (s *s3OutputStore) Put(ctx context.Context, key string, data []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return fmt.Errorf("failed to store output: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to store output: S3 returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Get downloads the object stored under key
+func (s *s3OutputStore) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load output: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("output %s not found", key)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to load output: S3 returned %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// Delete removes the object stored under key; deleting a missing object succeeds
+func (s *s3OutputStore) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete output: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("failed to delete output: S3 returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// do sends a signed request for an object in the bucket
+func (s *s3OutputStore) do(ctx context.Context, method string, key string, body []byte) (*http.Response, error) {
+	segments := strings.Split(s.bucket+"/"+key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	path := s.endpoint.EscapedPath() + "/" + strings.Join(segments, "/")
+
+	req, err := http.NewRequestWithContext(ctx, method, s.endpoint.Scheme+"://"+s.endpoint.Host+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, path, body, time.Now().UTC())
+
+	return s.httpClient.Do(req)
+}
+
+// sign adds AWS Signature V4 headers to req
+func (s *s3OutputStore) sign(req *http.Request, path string, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		canonicalQueryString(req.URL.Query()),
+		"host:" + req.URL.Host + "\nx-amz-content-sha256:" + payloadHash + "\nx-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+// canonicalQueryString sorts and encodes query parameters the way Signature V4 expects
+func canonicalQueryString(query url.Values) string {
+	pairs := make([]string, 0, len(query))
+	for name, values := range query {
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, awsURIEscape(name)+"="+awsURIEscape(value))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// awsURIEscape percent-encodes everything but unreserved characters, as Signature V4 requires
+func awsURIEscape(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(url.QueryEscape(s), "+", "%20"), "%7E", "~")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
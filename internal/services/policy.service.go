@@ -0,0 +1,278 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"ignis/internal/config"
+	"ignis/internal/models"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm/clause"
+)
+
+// ErrPolicyLimitReached is returned when an action would exceed a limit of the user's tier
+var ErrPolicyLimitReached = errors.New("plan limit reached")
+
+// defaultTierLimits apply to tiers without a POLICY_TIER_<NAME> override
+var defaultTierLimits = map[models.UserTier]models.TierLimits{
+	models.UserTierFree: {
+		APIKeyRateLimit:     5,
+		MaxAPIKeys:          5,
+		MaxWebhooks:         5,
+		DailyComputeMinutes: 60,
+		MaxCodeBytes:        64 * 1024,
+	},
+	models.UserTierPro: {
+		APIKeyRateLimit:     60,
+		MaxAPIKeys:          25,
+		MaxWebhooks:         25,
+		DailyComputeMinutes: 600,
+		MaxCodeBytes:        256 * 1024,
+	},
+	models.UserTierEnterprise: {
+		APIKeyRateLimit:     600,
+		MaxAPIKeys:          100,
+		MaxWebhooks:         100,
+		DailyComputeMinutes: 0,
+		MaxCodeBytes:        1024 * 1024,
+	},
+}
+
+// PolicyService resolves which tier a user is on and enforces that tier's limits
+type PolicyService struct {
+	dbService   *DBService
+	tiers       map[models.UserTier]models.TierLimits
+	defaultTier models.UserTier
+}
+
+// NewPolicyService creates a new instance of PolicyService
+func NewPolicyService(dbService *DBService) *PolicyService {
+	defaultTier, err := models.ParseUserTier(config.GetEnv("POLICY_DEFAULT_TIER", string(models.UserTierFree)))
+	if err != nil {
+		log.WithError(err).Warn("Invalid POLICY_DEFAULT_TIER, using free")
+		defaultTier = models.UserTierFree
+	}
+
+	return &PolicyService{
+		dbService:   dbService,
+		tiers:       loadTierLimits(),
+		defaultTier: defaultTier,
+	}
+}
+
+// loadTierLimits applies POLICY_TIER_FREE, POLICY_TIER_PRO and POLICY_TIER_ENTERPRISE overrides,
+// e.g. "rate_limit=10,api_keys=5,webhooks=5,compute_minutes=120,code_bytes=65536",
+// to the defaults
+func loadTierLimits() map[models.UserTier]models.TierLimits {
+	tiers := make(map[models.UserTier]models.TierLimits, len(defaultTierLimits))
+	for tier, limits := range defaultTierLimits {
+		envKey := "POLICY_TIER_" + strings.ToUpper(string(tier))
+		for name, raw := range config.GetEnvMap(envKey) {
+			value, err := strconv.Atoi(raw)
+			if err != nil || value < 0 {
+				log.WithFields(log.Fields{"tier": tier, "limit": name}).Warn("Ignoring invalid tier limit")
+				continue
+			}
+			switch name {
+			case "rate_limit":
+				limits.APIKeyRateLimit = value
+			case "api_keys":
+				limits.MaxAPIKeys = value
+			case "webhooks":
+				limits.MaxWebhooks = value
+			case "compute_minutes":
+				limits.DailyComputeMinutes = value
+			case "code_bytes":
+				limits.MaxCodeBytes = value
+			default:
+				log.WithFields(log.Fields{"tier": tier, "limit": name}).Warn("Ignoring unknown tier limit")
+			}
+		}
+		tiers[tier] = limits
+	}
+	return tiers
+}
+
+// TierFor returns the tier assigned to a user, or the default tier
+func (s *PolicyService) TierFor(clerkUserID string) models.UserTier {
+	var quota models.UserQuota
+	if err := s.dbService.FindOne(&quota, "clerk_user_id = ?", clerkUserID); err != nil {
+		return s.defaultTier
+	}
+	if _, ok := s.tiers[quota.Tier]; !ok {
+		return s.defaultTier
+	}
+	return quota.Tier
+}
+
+// LimitsFor returns the limits of the user's tier
+func (s *PolicyService) LimitsFor(clerkUserID string) models.TierLimits {
+	return s.tiers[s.TierFor(clerkUserID)]
+}
+
+// SetTier assigns a tier to a user
+func (s *PolicyService) SetTier(clerkUserID string, tier models.UserTier) error {
+	quota := models.UserQuota{ClerkUserID: clerkUserID, Tier: tier}
+	err := s.dbService.GetDB().Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "clerk_user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"tier", "updated_at"}),
+	}).Create(&quota).Error
+	if err != nil {
+		return fmt.Errorf("failed to set tier: %w", err)
+	}
+
+	log.WithFields(log.Fields{
+		"clerk_user_id": clerkUserID,
+		"tier":          tier,
+	}).Info("User tier updated")
+	return nil
+}
+
+// GetAccount returns the user's tier, its limits and the user's current usage
+func (s *PolicyService) GetAccount(clerkUserID string) (*models.AccountResponse, error) {
+	tier := s.TierFor(clerkUserID)
+
+	var usage models.AccountUsage
+	var err error
+	if usage.APIKeys, err = s.dbService.Count(&models.APIKey{}, "clerk_user_id = ? AND is_active = ?", clerkUserID, true); err != nil {
+		return nil, fmt.Errorf("failed to count API keys: %w", err)
+	}
+	if usage.Webhooks, err = s.dbService.Count(&models.Webhook{}, "clerk_user_id = ?", clerkUserID); err != nil {
+		return nil, fmt.Errorf("failed to count webhooks: %w", err)
+	}
+	if usage.ComputeMinutesToday, err = s.computeMinutesToday(clerkUserID); err != nil {
+		return nil, err
+	}
+
+	return &models.AccountResponse{
+		ClerkUserID: clerkUserID,
+		Tier:        tier,
+		Limits:      s.tiers[tier],
+		Usage:       usage,
+	}, nil
+}
+
+// CheckAPIKeyCount returns ErrPolicyLimitReached when the user already holds their tier's
+// maximum of active API keys
+func (s *PolicyService) CheckAPIKeyCount(clerkUserID string) error {
+	limits := s.LimitsFor(clerkUserID)
+	if limits.MaxAPIKeys == 0 {
+		return nil
+	}
+
+	count, err := s.dbService.Count(&models.APIKey{}, "clerk_user_id = ? AND is_active = ?", clerkUserID, true)
+	if err != nil {
+		return fmt.Errorf("failed to count API keys: %w", err)
+	}
+	if count >= int64(limits.MaxAPIKeys) {
+		return fmt.Errorf("%w: at most %d active API keys", ErrPolicyLimitReached, limits.MaxAPIKeys)
+	}
+	return nil
+}
+
+// CheckWebhookCount returns ErrPolicyLimitReached when the user already has their tier's
+// maximum of webhooks
+func (s *PolicyService) CheckWebhookCount(clerkUserID string) error {
+	limits := s.LimitsFor(clerkUserID)
+	if limits.MaxWebhooks == 0 {
+		return nil
+	}
+
+	count, err := s.dbService.Count(&models.Webhook{}, "clerk_user_id = ?", clerkUserID)
+	if err != nil {
+		return fmt.Errorf("failed to count webhooks: %w", err)
+	}
+	if count >= int64(limits.MaxWebhooks) {
+		return fmt.Errorf("%w: at most %d webhooks", ErrPolicyLimitReached, limits.MaxWebhooks)
+	}
+	return nil
+}
+
+// CheckJobSubmission returns ErrPolicyLimitReached when code is larger than the user's tier
+// allows or the user has used up today's compute minutes
+func (s *PolicyService) CheckJobSubmission(clerkUserID string, code string) error {
+	limits := s.LimitsFor(clerkUserID)
+
+	if err := checkCodeSize(limits, code); err != nil {
+		return err
+	}
+
+	if limits.DailyComputeMinutes > 0 {
+		used, err := s.computeMinutesToday(clerkUserID)
+		if err != nil {
+			return err
+		}
+		if used >= float64(limits.DailyComputeMinutes) {
+			return fmt.Errorf("%w: daily compute time of %d minutes used up", ErrPolicyLimitReached, limits.DailyComputeMinutes)
+		}
+	}
+	return nil
+}
+
+// CheckCodeSize returns ErrPolicyLimitReached when code is larger than the user's tier allows
+func (s *PolicyService) CheckCodeSize(clerkUserID string, code string) error {
+	return checkCodeSize(s.LimitsFor(clerkUserID), code)
+}
+
+func checkCodeSize(limits models.TierLimits, code string) error {
+	if limits.MaxCodeBytes > 0 && len(code) > limits.MaxCodeBytes {
+		return fmt.Errorf("%w: code may be at most %d bytes", ErrPolicyLimitReached, limits.MaxCodeBytes)
+	}
+	return nil
+}
+
+// GetLimits returns the limits of the user's tier alongside their pending jobs and today's compute
+// time. Rate limits depend on how the caller authenticated, so they're left to the caller.
+func (s *PolicyService) GetLimits(clerkUserID string) (*models.AccountLimitsResponse, error) {
+	tier := s.TierFor(clerkUserID)
+	limits := s.tiers[tier]
+
+	pending, err := s.countPendingJobs(clerkUserID)
+	if err != nil {
+		return nil, err
+	}
+	computeMinutes, err := s.computeMinutesToday(clerkUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AccountLimitsResponse{
+		Tier:                tier,
+		DailyComputeMinutes: limits.DailyComputeMinutes,
+		MaxCodeBytes:        limits.MaxCodeBytes,
+		Usage: models.AccountLimitsUsage{
+			PendingJobs:         pending,
+			ComputeMinutesToday: computeMinutes,
+		},
+	}, nil
+}
+
+// countPendingJobs counts the user's jobs that are scheduled, waiting for a worker or running
+func (s *PolicyService) countPendingJobs(clerkUserID string) (int64, error) {
+	count, err := s.dbService.Count(&models.Job{}, "clerk_user_id = ? AND status IN ?", clerkUserID,
+		[]models.JobStatus{models.JobStatusScheduled, models.JobStatusReceived, models.JobStatusRunning})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count pending jobs: %w", err)
+	}
+	return count, nil
+}
+
+// computeMinutesToday sums the execution time, reported by workers in milliseconds, of the
+// user's jobs submitted since midnight UTC
+func (s *PolicyService) computeMinutesToday(clerkUserID string) (float64, error) {
+	midnight := time.Now().UTC().Truncate(24 * time.Hour)
+
+	var totalMs int64
+	err := s.dbService.GetDB().Model(&models.Job{}).
+		Select("COALESCE(SUM(exec_duration), 0)").
+		Where("clerk_user_id = ? AND created_at >= ?", clerkUserID, midnight).
+		Scan(&totalMs).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum compute time: %w", err)
+	}
+	return float64(totalMs) / float64(time.Minute/time.Millisecond), nil
+}
@@ -5,9 +5,12 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"strconv"
 	"sync"
 	"time"
 
+	"ignis/internal/config"
+
 	"github.com/go-redis/redis/v8"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/time/rate"
@@ -16,6 +19,8 @@ import (
 // RateLimiter interface for rate limiting implementations
 type RateLimiter interface {
 	Allow(key string, limit int, window time.Duration) (bool, error)
+	AllowN(key string, n int, limit int, window time.Duration, partial bool) (int, error)
+	ReturnN(key string, n int) error
 	Reset(key string) error
 }
 
@@ -24,6 +29,8 @@ type RateLimiterService struct {
 	redisClient     *redis.Client
 	inMemoryLimiter *InMemoryRateLimiter
 	useRedis        bool
+	snapshotClient  *redis.Client // Saves the in-memory limiter across restarts; nil when RATE_LIMIT_SNAPSHOT_REDIS_URL is unset
+	warningFraction float64       // Warn clients once fewer than this share of their limit remains; 0 disables
 }
 
 // InMemoryRateLimiter provides fallback rate limiting
@@ -45,6 +52,7 @@ func NewRateLimiterService(redisURL string) *RateLimiterService {
 		inMemoryLimiter: &InMemoryRateLimiter{
 			limiters: make(map[string]*rate.Limiter),
 		},
+		warningFraction: config.GetEnvFloat("RATE_LIMIT_WARNING_FRACTION", 0.2),
 	}
 
 	if redisURL != "" {
@@ -69,6 +77,9 @@ func NewRateLimiterService(redisURL string) *RateLimiterService {
 
 	if !service.useRedis {
 		log.Info("Using in-memory rate limiting")
+		if service.snapshotClient = newSnapshotClient(); service.snapshotClient != nil {
+			service.loadSnapshot()
+		}
 	}
 
 	return service
@@ -76,14 +87,37 @@ func NewRateLimiterService(redisURL string) *RateLimiterService {
 
 // Allow checks if a request should be allowed based on rate limits
 func (r *RateLimiterService) Allow(key string, limit int, window time.Duration) (bool, error) {
+	result, err := r.Check(key, limit, window)
+	return result.Allowed, err
+}
+
+// Check consumes one request like Allow and also reports how many requests remain in the window
+func (r *RateLimiterService) Check(key string, limit int, window time.Duration) (RateLimitResult, error) {
+	var allowed bool
+	var remaining int
+	var err error
 	if r.useRedis {
-		return r.allowRedis(key, limit, window)
+		allowed, remaining, err = r.allowRedis(key, limit, window)
+	} else {
+		allowed, remaining = r.inMemoryLimiter.Check(key, limit, window)
 	}
-	return r.inMemoryLimiter.Allow(key, limit, window), nil
+
+	return RateLimitResult{
+		Allowed:   allowed,
+		Remaining: remaining,
+		ResetAt:   time.Now().Add(window),
+	}, err
+}
+
+// ShouldWarn reports whether a client with remaining requests left of limit is close enough to
+// being limited to be warned, per RATE_LIMIT_WARNING_FRACTION
+func (r *RateLimiterService) ShouldWarn(remaining int, limit int) bool {
+	return r.warningFraction > 0 && limit > 0 && float64(remaining) < r.warningFraction*float64(limit)
 }
 
-// allowRedis implements sliding window rate limiting using Redis
-func (r *RateLimiterService) allowRedis(key string, limit int, window time.Duration) (bool, error) {
+// allowRedis implements sliding window rate limiting using Redis, returning whether the request
+// is allowed and how many remain in the window
+func (r *RateLimiterService) allowRedis(key string, limit int, window time.Duration) (bool, int, error) {
 	ctx := context.Background()
 	now := time.Now()
 	windowStart := now.Add(-window)
@@ -117,12 +151,124 @@ func (r *RateLimiterService) allowRedis(key string, limit int, window time.Durat
 	if err != nil {
 		log.WithError(err).Error("Redis rate limit check failed")
 		// Fallback to in-memory
-		return r.inMemoryLimiter.Allow(key, limit, window), nil
+		allowed, remaining := r.inMemoryLimiter.Check(key, limit, window)
+		return allowed, remaining, nil
 	}
 
 	resultSlice := result.([]interface{})
 	allowed := resultSlice[0].(int64) == 1
-	return allowed, nil
+	return allowed, int(resultSlice[1].(int64)), nil
+}
+
+// AllowN atomically consumes n units of a key's limit. If fewer than n are left it consumes
+// nothing, or with partial set, as many as remain. It returns how many units were granted.
+func (r *RateLimiterService) AllowN(key string, n int, limit int, window time.Duration, partial bool) (int, error) {
+	if r.useRedis {
+		return r.allowNRedis(key, n, limit, window, partial)
+	}
+	return r.inMemoryLimiter.AllowN(key, n, limit, window, partial), nil
+}
+
+// allowNRedis implements AllowN on the same sliding window as allowRedis
+func (r *RateLimiterService) allowNRedis(key string, n int, limit int, window time.Duration, partial bool) (int, error) {
+	ctx := context.Background()
+	now := time.Now()
+	windowStart := now.Add(-window)
+
+	luaScript := `
+		local key = KEYS[1]
+		local window_start = tonumber(ARGV[1])
+		local now = tonumber(ARGV[2])
+		local limit = tonumber(ARGV[3])
+		local n = tonumber(ARGV[4])
+		local partial = tonumber(ARGV[5])
+
+		redis.call('ZREMRANGEBYSCORE', key, '-inf', window_start)
+
+		local available = limit - redis.call('ZCARD', key)
+		if available < 0 then
+			available = 0
+		end
+
+		local grant = n
+		if grant > available then
+			if partial == 1 then
+				grant = available
+			else
+				grant = 0
+			end
+		end
+
+		for i = 1, grant do
+			redis.call('ZADD', key, now, now .. '-' .. i)
+		end
+		if grant > 0 then
+			redis.call('EXPIRE', key, 3600)
+		end
+		return grant
+	`
+
+	partialArg := 0
+	if partial {
+		partialArg = 1
+	}
+
+	result, err := r.redisClient.Eval(ctx, luaScript, []string{key},
+		windowStart.UnixNano(), now.UnixNano(), limit, n, partialArg).Result()
+	if err != nil {
+		log.WithError(err).Error("Redis rate limit check failed")
+		// Fallback to in-memory
+		return r.inMemoryLimiter.AllowN(key, n, limit, window, partial), nil
+	}
+
+	return int(result.(int64)), nil
+}
+
+// ReturnN gives back n units consumed by AllowN, e.g. for work that was rejected afterwards
+func (r *RateLimiterService) ReturnN(key string, n int) error {
+	if n <= 0 {
+		return nil
+	}
+	if r.useRedis {
+		// Drop the newest entries; which ones doesn't matter, only how many are in the window
+		if err := r.redisClient.ZPopMax(context.Background(), key, int64(n)).Err(); err != nil {
+			return fmt.Errorf("failed to return rate limit units: %w", err)
+		}
+		return nil
+	}
+	r.inMemoryLimiter.ReturnN(key, n)
+	return nil
+}
+
+// Peek reports how many requests a key has used in the current window and how many remain,
+// without consuming any. The in-memory limiter is a token bucket, so its usage is derived
+// from the tokens left.
+func (r *RateLimiterService) Peek(key string, limit int, window time.Duration) (int, int, error) {
+	if !r.useRedis {
+		remaining := r.inMemoryLimiter.Peek(key, limit)
+		return limit - remaining, remaining, nil
+	}
+
+	ctx := context.Background()
+	windowStart := time.Now().Add(-window)
+	used, err := r.redisClient.ZCount(ctx, key, strconv.FormatInt(windowStart.UnixNano(), 10), "+inf").Result()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read rate limit usage: %w", err)
+	}
+
+	remaining := limit - int(used)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return int(used), remaining, nil
+}
+
+// Backend names the limiter in use: "redis" or "memory"
+func (r *RateLimiterService) Backend() string {
+	if r.useRedis {
+		return "redis"
+	}
+	return "memory"
 }
 
 // Reset removes rate limit data for a key
@@ -137,9 +283,20 @@ func (r *RateLimiterService) Reset(key string) error {
 
 // Allow implements in-memory rate limiting using token bucket
 func (i *InMemoryRateLimiter) Allow(key string, limit int, window time.Duration) bool {
+	allowed, _ := i.Check(key, limit, window)
+	return allowed
+}
+
+// Check takes a token like Allow and also returns how many whole tokens are left
+func (i *InMemoryRateLimiter) Check(key string, limit int, window time.Duration) (bool, int) {
 	i.mutex.Lock()
 	defer i.mutex.Unlock()
 
+	// A limit of zero or less allows nothing, like the Redis limiter
+	if limit <= 0 {
+		return false, 0
+	}
+
 	limiter, exists := i.limiters[key]
 	if !exists {
 		// Create new limiter with token bucket: limit tokens per window
@@ -147,7 +304,76 @@ func (i *InMemoryRateLimiter) Allow(key string, limit int, window time.Duration)
 		i.limiters[key] = limiter
 	}
 
-	return limiter.Allow()
+	allowed := limiter.Allow()
+	remaining := int(limiter.Tokens())
+	if remaining < 0 {
+		remaining = 0
+	}
+	return allowed, remaining
+}
+
+// AllowN implements all-or-nothing (or partial) consumption of n tokens
+func (i *InMemoryRateLimiter) AllowN(key string, n int, limit int, window time.Duration, partial bool) int {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	if limit <= 0 {
+		return 0
+	}
+
+	limiter, exists := i.limiters[key]
+	if !exists {
+		limiter = rate.NewLimiter(rate.Every(window/time.Duration(limit)), limit)
+		i.limiters[key] = limiter
+	}
+
+	grant := n
+	if available := int(limiter.Tokens()); grant > available {
+		if !partial {
+			return 0
+		}
+		grant = available
+	}
+	if grant <= 0 || !limiter.AllowN(time.Now(), grant) {
+		return 0
+	}
+	return grant
+}
+
+// ReturnN puts n tokens back into a key's bucket, never filling it past its burst
+func (i *InMemoryRateLimiter) ReturnN(key string, n int) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	limiter, exists := i.limiters[key]
+	if !exists {
+		return
+	}
+
+	// rate.Limiter can't add tokens, so replace the bucket with one holding n more
+	now := time.Now()
+	refilled := rate.NewLimiter(limiter.Limit(), limiter.Burst())
+	if used := limiter.Burst() - int(limiter.TokensAt(now)+0.999) - n; used > 0 {
+		refilled.AllowN(now, used)
+	}
+	i.limiters[key] = refilled
+}
+
+// Peek returns how many whole tokens a key has left without taking one; unseen keys have the full limit
+func (i *InMemoryRateLimiter) Peek(key string, limit int) int {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	limiter, exists := i.limiters[key]
+	if !exists {
+		return limit
+	}
+
+	remaining := int(limiter.TokensAt(time.Now()))
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining
 }
 
 // Reset removes a limiter for a key
@@ -186,6 +412,17 @@ func GetAPIKeyRateLimitKey(apiKeyID, endpoint string) string {
 	return GenerateRateLimitKey("api", apiKeyID, endpoint)
 }
 
+// GetAPIKeyJobQuotaKey creates the key for an API key's job submission quota, shared by single and batch submissions
+func GetAPIKeyJobQuotaKey(apiKeyID string) string {
+	return GenerateRateLimitKey("api", apiKeyID, "job_submissions")
+}
+
+// GetWebhookDeliveryRateLimitKey creates a rate limit key for outbound webhook deliveries. The limit
+// is part of the key so a changed limit takes effect immediately with the in-memory limiter.
+func GetWebhookDeliveryRateLimitKey(webhookID string, limit int) string {
+	return GenerateRateLimitKey("webhook", webhookID, fmt.Sprintf("delivery:%d", limit))
+}
+
 // GetGlobalRateLimitKey creates a rate limit key for global limits
 func GetGlobalRateLimitKey(endpoint string) string {
 	return GenerateRateLimitKey("global", "all", endpoint)
@@ -193,6 +430,9 @@ func GetGlobalRateLimitKey(endpoint string) string {
 
 // Close closes the rate limiter service
 func (r *RateLimiterService) Close() error {
+	if r.snapshotClient != nil {
+		r.snapshotClient.Close()
+	}
 	if r.redisClient != nil {
 		return r.redisClient.Close()
 	}
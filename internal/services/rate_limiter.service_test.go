@@ -0,0 +1,37 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestInMemoryRateLimiterZeroLimit(t *testing.T) {
+	limiter := &InMemoryRateLimiter{limiters: make(map[string]*rate.Limiter)}
+
+	if granted := limiter.AllowN("key", 3, 0, time.Minute, true); granted != 0 {
+		t.Errorf("expected nothing granted with a zero limit, got %d", granted)
+	}
+	if allowed, remaining := limiter.Check("key", 0, time.Minute); allowed || remaining != 0 {
+		t.Errorf("expected a zero limit to deny, got allowed=%v remaining=%d", allowed, remaining)
+	}
+}
+
+func TestInMemoryRateLimiterReturnN(t *testing.T) {
+	limiter := &InMemoryRateLimiter{limiters: make(map[string]*rate.Limiter)}
+
+	if granted := limiter.AllowN("key", 5, 10, time.Hour, false); granted != 5 {
+		t.Fatalf("expected 5 granted, got %d", granted)
+	}
+
+	limiter.ReturnN("key", 3)
+	if remaining := limiter.Peek("key", 10); remaining != 8 {
+		t.Errorf("expected 8 remaining after returning 3, got %d", remaining)
+	}
+
+	limiter.ReturnN("key", 5)
+	if remaining := limiter.Peek("key", 10); remaining != 10 {
+		t.Errorf("expected the bucket to stop at its burst, got %d remaining", remaining)
+	}
+}
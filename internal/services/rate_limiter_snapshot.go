@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"ignis/internal/config"
+
+	"github.com/go-redis/redis/v8"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+)
+
+// rateLimitSnapshotKey is the Redis key the in-memory limiter state is saved under
+const rateLimitSnapshotKey = "rate_limit:snapshot"
+
+// rateLimitSnapshotTTL is how long a saved snapshot is kept; buckets refill well within it
+const rateLimitSnapshotTTL = time.Hour
+
+// limiterSnapshot is the saved state of one in-memory token bucket
+type limiterSnapshot struct {
+	Rate   float64 `json:"rate"` // tokens per second
+	Burst  int     `json:"burst"`
+	Tokens float64 `json:"tokens"`
+}
+
+// rateLimitSnapshot is the saved state of the whole in-memory limiter
+type rateLimitSnapshot struct {
+	SavedAt  time.Time                  `json:"saved_at"`
+	Limiters map[string]limiterSnapshot `json:"limiters"`
+}
+
+// newSnapshotClient connects to RATE_LIMIT_SNAPSHOT_REDIS_URL, the Redis the in-memory limiter
+// is saved to across restarts. It returns nil when snapshots are off or Redis is unreachable.
+func newSnapshotClient() *redis.Client {
+	snapshotURL := config.GetEnv("RATE_LIMIT_SNAPSHOT_REDIS_URL", "")
+	if snapshotURL == "" {
+		return nil
+	}
+
+	opt, err := redis.ParseURL(snapshotURL)
+	if err != nil {
+		log.WithError(err).Warn("Failed to parse rate limit snapshot Redis URL, limits won't survive restarts")
+		return nil
+	}
+
+	rdb := redis.NewClient(opt)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		log.WithError(err).Warn("Failed to connect to rate limit snapshot Redis, limits won't survive restarts")
+		rdb.Close()
+		return nil
+	}
+	return rdb
+}
+
+// SaveSnapshot writes the in-memory limiter state to the snapshot Redis so the next process can
+// pick it up. It is best-effort: failures are logged and nothing is saved when Redis limiting is
+// in use or snapshots aren't configured.
+func (r *RateLimiterService) SaveSnapshot(ctx context.Context) {
+	if r.useRedis || r.snapshotClient == nil {
+		return
+	}
+
+	snapshot := r.inMemoryLimiter.snapshot()
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		log.WithError(err).Warn("Failed to encode rate limit snapshot")
+		return
+	}
+	if err := r.snapshotClient.Set(ctx, rateLimitSnapshotKey, data, rateLimitSnapshotTTL).Err(); err != nil {
+		log.WithError(err).Warn("Failed to save rate limit snapshot")
+		return
+	}
+
+	log.WithField("limiters", len(snapshot.Limiters)).Info("Saved rate limit snapshot")
+}
+
+// loadSnapshot restores the in-memory limiter from the snapshot saved by the previous process,
+// crediting the tokens its buckets refilled since then
+func (r *RateLimiterService) loadSnapshot() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	data, err := r.snapshotClient.Get(ctx, rateLimitSnapshotKey).Bytes()
+	if err == redis.Nil {
+		return
+	}
+	if err != nil {
+		log.WithError(err).Warn("Failed to load rate limit snapshot")
+		return
+	}
+
+	var snapshot rateLimitSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		log.WithError(err).Warn("Failed to decode rate limit snapshot")
+		return
+	}
+
+	restored := r.inMemoryLimiter.restore(snapshot)
+	log.WithFields(log.Fields{
+		"limiters": restored,
+		"saved_at": snapshot.SavedAt,
+	}).Info("Restored rate limit snapshot")
+}
+
+// snapshot captures the tokens left in every bucket
+func (i *InMemoryRateLimiter) snapshot() rateLimitSnapshot {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	now := time.Now()
+	snapshot := rateLimitSnapshot{SavedAt: now, Limiters: make(map[string]limiterSnapshot, len(i.limiters))}
+	for key, limiter := range i.limiters {
+		tokens := limiter.TokensAt(now)
+		// Full buckets behave the same as new ones, so they aren't worth saving
+		if tokens >= float64(limiter.Burst()) {
+			continue
+		}
+		snapshot.Limiters[key] = limiterSnapshot{
+			Rate:   float64(limiter.Limit()),
+			Burst:  limiter.Burst(),
+			Tokens: tokens,
+		}
+	}
+	return snapshot
+}
+
+// restore recreates the saved buckets as they were at snapshot.SavedAt; they refill from there.
+// Keys already in use by this process are left alone. It returns how many buckets were restored.
+func (i *InMemoryRateLimiter) restore(snapshot rateLimitSnapshot) int {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	restored := 0
+	for key, saved := range snapshot.Limiters {
+		if _, exists := i.limiters[key]; exists || saved.Burst <= 0 {
+			continue
+		}
+
+		limiter := rate.NewLimiter(rate.Limit(saved.Rate), saved.Burst)
+		// Round the used tokens down so clients never lose more than they had used
+		if used := saved.Burst - int(saved.Tokens+0.999); used > 0 {
+			limiter.AllowN(snapshot.SavedAt, min(used, saved.Burst))
+		}
+		i.limiters[key] = limiter
+		restored++
+	}
+	return restored
+}
@@ -6,38 +6,121 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
+	"ignis/internal/config"
 	"ignis/internal/models"
 
 	log "github.com/sirupsen/logrus"
 )
 
+// maxWebhookRedirects bounds how many redirects a single delivery may follow
+const maxWebhookRedirects = 3
+
+// ErrWebhookDeleteUnconfirmed is returned when deleting a webhook that delivered events within
+// WEBHOOK_DELETE_CONFIRM_WINDOW without confirmation
+var ErrWebhookDeleteUnconfirmed = errors.New("webhook delivered events recently; confirm the deletion")
+
+// errWebhookRedirectBlocked marks deliveries stopped because a redirect was not allowed
+var errWebhookRedirectBlocked = errors.New("webhook redirect blocked")
+
 // WebhookService handles webhook operations
 type WebhookService struct {
-	dbService  *DBService
-	httpClient *http.Client
+	dbService            *DBService
+	rateLimiter          *RateLimiterService
+	policy               *PolicyService
+	httpClient           *http.Client
+	defaultEvents        models.WebhookEventTypes // Used when a webhook is created without events; empty keeps validation strict
+	allowPrivateNetworks bool                     // Permits loopback/private targets, e.g. for local development
+	requireVerification  bool                     // Only deliver to webhooks whose endpoint passed the verification challenge
+	dedupeDeliveries     bool                     // Send an event once to webhooks sharing a URL and secret
+	selfHosts            []string                 // Hostnames of this service, which webhooks may not target
+	deleteConfirmWindow  time.Duration            // Deleting a webhook with deliveries this recent needs confirmation; 0 disables
+	secretRotationGrace  time.Duration            // How long a rotated-out secret keeps signing deliveries; 0 drops it at once
+	eventsCursorLag      time.Duration            // since_id listings leave out events newer than this, whose lower IDs may still be committing
+	delivery             webhookDeliveryConfig
+	wake                 chan struct{} // Signals the delivery queue that new events were enqueued
 }
 
 // NewWebhookService creates a new webhook service
-func NewWebhookService(dbService *DBService) *WebhookService {
-	return &WebhookService{
-		dbService: dbService,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+func NewWebhookService(dbService *DBService, rateLimiter *RateLimiterService, policy *PolicyService) *WebhookService {
+	service := &WebhookService{
+		dbService:            dbService,
+		rateLimiter:          rateLimiter,
+		policy:               policy,
+		defaultEvents:        loadWebhookDefaultEvents(),
+		allowPrivateNetworks: config.GetEnvBool("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false),
+		requireVerification:  config.GetEnvBool("WEBHOOK_REQUIRE_VERIFICATION", false),
+		dedupeDeliveries:     config.GetEnvBool("WEBHOOK_DEDUPE_DELIVERIES", false),
+		selfHosts:            loadWebhookSelfHosts(),
+		deleteConfirmWindow:  config.GetEnvDuration("WEBHOOK_DELETE_CONFIRM_WINDOW", 24*time.Hour),
+		secretRotationGrace:  config.GetEnvDuration("WEBHOOK_SECRET_ROTATION_GRACE", 24*time.Hour),
+		eventsCursorLag:      config.GetEnvDuration("WEBHOOK_EVENTS_CURSOR_LAG", 5*time.Second),
+		delivery:             loadWebhookDeliveryConfig(),
+		wake:                 make(chan struct{}, 1),
+	}
+
+	service.httpClient = &http.Client{
+		Timeout: config.GetEnvDuration("WEBHOOK_TIMEOUT", 30*time.Second),
+		// Re-validate every redirect target so a receiver can't bounce us to an internal address
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxWebhookRedirects {
+				return fmt.Errorf("%w: stopped after %d redirects", errWebhookRedirectBlocked, maxWebhookRedirects)
+			}
+			if err := service.validateURL(req.URL.String()); err != nil {
+				return fmt.Errorf("%w: %v", errWebhookRedirectBlocked, err)
+			}
+			return nil
 		},
 	}
+
+	// Deliver queued events, including any left pending by a previous process
+	go service.runDeliveryQueue()
+	go service.runDeliveryReconciler()
+
+	return service
 }
 
 // CreateWebhook creates a new webhook configuration
 func (s *WebhookService) CreateWebhook(req models.WebhookCreateRequest, clerkUserID string) (*models.WebhookResponse, error) {
+	if _, err := s.validateURLFormat(req.URL); err != nil {
+		return nil, err
+	}
+
+	if err := validateEventTypes(req.Events); err != nil {
+		return nil, err
+	}
+
+	if err := s.policy.CheckWebhookCount(clerkUserID); err != nil {
+		return nil, err
+	}
+
+	events := req.Events
+	if len(events) == 0 {
+		if len(s.defaultEvents) == 0 {
+			return nil, invalidInput("at least one event is required")
+		}
+		events = append(models.WebhookEventTypes{}, s.defaultEvents...)
+	}
+
 	webhook := models.Webhook{
 		URL:         req.URL,
 		Secret:      req.Secret,
-		Events:      req.Events,
+		Events:      events,
 		IsActive:    true,
+		RateLimit:   req.RateLimit,
+		Canonical:   req.Canonical,
+		Format:      req.Format,
+		RetryPolicy: req.RetryPolicy,
+		RetryDelay:  req.RetryDelay,
+		MaxAttempts: req.MaxAttempts,
 		ClerkUserID: clerkUserID,
 	}
 
@@ -53,23 +136,52 @@ func (s *WebhookService) CreateWebhook(req models.WebhookCreateRequest, clerkUse
 		"clerk_user_id": clerkUserID,
 	}).Info("Webhook created")
 
+	// Challenge the endpoint right away; if it isn't ready yet the user can retry via VerifyWebhook
+	if s.requireVerification {
+		go s.verifyWebhook(&models.Webhook{ID: webhook.ID, URL: webhook.URL, Secret: webhook.Secret})
+	}
+
 	return s.toWebhookResponse(webhook), nil
 }
 
-// GetWebhooksByUser retrieves all webhooks for a user
-func (s *WebhookService) GetWebhooksByUser(clerkUserID string) ([]models.WebhookResponse, error) {
+// validateEventTypes rejects subscriptions to event types the service never sends
+func validateEventTypes(events models.WebhookEventTypes) error {
+	for _, event := range events {
+		if !event.IsKnown() {
+			return invalidInput("unknown event type %q", event)
+		}
+	}
+	return nil
+}
+
+// GetWebhooksByUser retrieves a filtered page of webhooks for a user along with the total match count
+func (s *WebhookService) GetWebhooksByUser(clerkUserID string, opts models.WebhookListOptions) ([]models.WebhookResponse, int64, error) {
+	query := s.dbService.GetDB().Model(&models.Webhook{}).Where("clerk_user_id = ?", clerkUserID)
+
+	if opts.IsActive != nil {
+		query = query.Where("is_active = ?", *opts.IsActive)
+	}
+	if opts.EventType != "" {
+		// events is stored as a JSON array, so match subscriptions via jsonb containment
+		eventFilter, err := json.Marshal([]models.WebhookEventType{opts.EventType})
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid event type filter: %w", err)
+		}
+		query = query.Where("events::jsonb @> ?::jsonb", string(eventFilter))
+	}
+
 	var webhooks []models.Webhook
-	err := s.dbService.FindWhere(&webhooks, "clerk_user_id = ?", clerkUserID)
+	total, err := findPage(query, &webhooks, "created_at DESC, id DESC", opts.Limit, opts.Offset)
 	if err != nil {
-		return nil, err
+		return nil, 0, fmt.Errorf("failed to fetch webhooks: %w", err)
 	}
 
-	var responses []models.WebhookResponse
+	responses := make([]models.WebhookResponse, 0, len(webhooks))
 	for _, webhook := range webhooks {
 		responses = append(responses, *s.toWebhookResponse(webhook))
 	}
 
-	return responses, nil
+	return responses, total, nil
 }
 
 // GetWebhookByID retrieves a webhook by ID for a specific user
@@ -77,7 +189,7 @@ func (s *WebhookService) GetWebhookByID(id uint, clerkUserID string) (*models.We
 	var webhook models.Webhook
 	err := s.dbService.FindOne(&webhook, "id = ? AND clerk_user_id = ?", id, clerkUserID)
 	if err != nil {
-		return nil, fmt.Errorf("webhook not found")
+		return nil, ErrWebhookNotFound
 	}
 
 	return s.toWebhookResponse(webhook), nil
@@ -88,22 +200,61 @@ func (s *WebhookService) UpdateWebhook(id uint, clerkUserID string, req models.W
 	var webhook models.Webhook
 	err := s.dbService.FindOne(&webhook, "id = ? AND clerk_user_id = ?", id, clerkUserID)
 	if err != nil {
-		return nil, fmt.Errorf("webhook not found")
+		return nil, ErrWebhookNotFound
 	}
 
-	// Update fields if provided
-	if req.URL != "" {
-		webhook.URL = req.URL
+	// Only touch the fields that were sent; an empty value clears the field
+	urlChanged := false
+	if req.URL != nil {
+		if *req.URL == "" {
+			return nil, invalidInput("url cannot be empty")
+		}
+		if _, err := s.validateURLFormat(*req.URL); err != nil {
+			return nil, err
+		}
+		urlChanged = *req.URL != webhook.URL
+		webhook.URL = *req.URL
 	}
-	if req.Secret != "" {
-		webhook.Secret = req.Secret
+	if req.Secret != nil {
+		webhook.Secret = *req.Secret
+		// A secret set by hand replaces the old one at once, unlike RotateWebhookSecret
+		webhook.PreviousSecret = ""
+		webhook.PreviousSecretExpiresAt = nil
 	}
-	if len(req.Events) > 0 {
-		webhook.Events = req.Events
+	if req.Events != nil {
+		if err := validateEventTypes(*req.Events); err != nil {
+			return nil, err
+		}
+		webhook.Events = append(models.WebhookEventTypes{}, *req.Events...)
 	}
 	if req.IsActive != nil {
 		webhook.IsActive = *req.IsActive
 	}
+	if req.RateLimit != nil {
+		webhook.RateLimit = *req.RateLimit
+	}
+	if req.Canonical != nil {
+		webhook.Canonical = *req.Canonical
+	}
+	if req.Format != nil {
+		webhook.Format = *req.Format
+		if webhook.Format == "" {
+			webhook.Format = models.WebhookFormatJSON
+		}
+	}
+	if req.RetryPolicy != nil {
+		webhook.RetryPolicy = *req.RetryPolicy
+	}
+	if req.RetryDelay != nil {
+		webhook.RetryDelay = *req.RetryDelay
+	}
+	if req.MaxAttempts != nil {
+		webhook.MaxAttempts = *req.MaxAttempts
+	}
+
+	if urlChanged {
+		s.resetVerification(&webhook)
+	}
 
 	err = s.dbService.Update(&webhook)
 	if err != nil {
@@ -115,20 +266,49 @@ func (s *WebhookService) UpdateWebhook(id uint, clerkUserID string, req models.W
 		"clerk_user_id": clerkUserID,
 	}).Info("Webhook updated")
 
+	if urlChanged && s.requireVerification {
+		go s.verifyWebhook(&models.Webhook{ID: webhook.ID, URL: webhook.URL, Secret: webhook.Secret, Reverify: webhook.Reverify})
+	}
+
 	return s.toWebhookResponse(webhook), nil
 }
 
+// resetVerification clears a webhook's verification after its URL changed, since it proved control
+// of the old URL only. When verification is required, an active webhook is paused until the new
+// URL passes it and is then reactivated.
+func (s *WebhookService) resetVerification(webhook *models.Webhook) {
+	webhook.Verified = false
+	webhook.VerifiedAt = nil
+	if s.requireVerification && webhook.IsActive {
+		webhook.IsActive = false
+		webhook.Reverify = true
+	}
+}
+
 // DeleteWebhook soft deletes a webhook
-func (s *WebhookService) DeleteWebhook(id uint, clerkUserID string) error {
+// Webhooks that delivered events within WEBHOOK_DELETE_CONFIRM_WINDOW are only deleted when
+// confirmed; otherwise ErrWebhookDeleteUnconfirmed is returned with the number of recent deliveries.
+func (s *WebhookService) DeleteWebhook(id uint, clerkUserID string, req models.WebhookDeleteRequest) (int64, error) {
 	var webhook models.Webhook
 	err := s.dbService.FindOne(&webhook, "id = ? AND clerk_user_id = ?", id, clerkUserID)
 	if err != nil {
-		return fmt.Errorf("webhook not found")
+		return 0, ErrWebhookNotFound
+	}
+
+	if s.deleteConfirmWindow > 0 && !req.Confirm && req.URL != webhook.URL {
+		recent, err := s.dbService.Count(&models.WebhookEvent{}, "webhook_id = ? AND delivered = ? AND updated_at >= ?",
+			webhook.ID, true, time.Now().Add(-s.deleteConfirmWindow))
+		if err != nil {
+			return 0, fmt.Errorf("failed to count recent deliveries: %w", err)
+		}
+		if recent > 0 {
+			return recent, ErrWebhookDeleteUnconfirmed
+		}
 	}
 
 	err = s.dbService.Delete(&webhook, webhook.ID)
 	if err != nil {
-		return fmt.Errorf("failed to delete webhook: %w", err)
+		return 0, fmt.Errorf("failed to delete webhook: %w", err)
 	}
 
 	log.WithFields(log.Fields{
@@ -136,30 +316,16 @@ func (s *WebhookService) DeleteWebhook(id uint, clerkUserID string) error {
 		"clerk_user_id": clerkUserID,
 	}).Info("Webhook deleted")
 
-	return nil
+	return 0, nil
 }
 
 // SendWebhookEvent sends a webhook event for a job
 func (s *WebhookService) SendWebhookEvent(job *models.JobWebhookResponse, clerkUserID string, eventType models.WebhookEventType) error {
-	// Find all active webhooks for the user that are subscribed to this event type
-	var webhooks []models.Webhook
-	err := s.dbService.FindWhere(&webhooks, "clerk_user_id = ? AND is_active = ?", clerkUserID, true)
+	subscribedWebhooks, err := s.subscribedWebhooks(clerkUserID, eventType)
 	if err != nil {
-		log.WithError(err).Error("Failed to fetch webhooks for user")
 		return err
 	}
 
-	// Filter webhooks by event type
-	var subscribedWebhooks []models.Webhook
-	for _, webhook := range webhooks {
-		for _, event := range webhook.Events {
-			if event == eventType {
-				subscribedWebhooks = append(subscribedWebhooks, webhook)
-				break
-			}
-		}
-	}
-
 	if len(subscribedWebhooks) == 0 {
 		log.WithFields(log.Fields{
 			"job_id":     job.JobID,
@@ -176,141 +342,265 @@ func (s *WebhookService) SendWebhookEvent(job *models.JobWebhookResponse, clerkU
 		Job:       *job,
 	}
 
-	// Send to all subscribed webhooks
+	// Serialize once; every subscribed webhook receives the same payload
+	payloadBytes, err := s.marshalWebhookPayload(payload, subscribedWebhooks, eventType, job.JobID)
+	if err != nil {
+		return err
+	}
+
+	// Webhooks that opted into canonical JSON get the same payload with sorted keys, and
+	// form-format webhooks get it flattened and form-encoded; each is encoded at most once
+	var canonicalBytes, formBytes []byte
+	for _, webhook := range subscribedWebhooks {
+		switch {
+		case webhook.Format == models.WebhookFormatForm && formBytes == nil:
+			if formBytes, err = formEncodePayload(payloadBytes); err != nil {
+				log.WithError(err).WithField("job_id", job.JobID).Error("Failed to form-encode webhook payload")
+			}
+		case webhook.Format != models.WebhookFormatForm && webhook.Canonical && canonicalBytes == nil:
+			if canonicalBytes, err = canonicalJSON(payloadBytes); err != nil {
+				log.WithError(err).WithField("job_id", job.JobID).Error("Failed to canonicalize webhook payload")
+			}
+		}
+	}
+
+	// Queue a delivery for each subscribed webhook; the delivery workers send them. With
+	// WEBHOOK_DEDUPE_DELIVERIES, webhooks that would receive the exact same request share one delivery.
+	queued := make(map[string]uint)
 	for _, webhook := range subscribedWebhooks {
-		go s.sendWebhookEventAsync(webhook, payload, job.JobID)
+		dedupeKey := webhook.URL + "\x00" + webhook.Secret + "\x00" + activePreviousSecret(webhook) + "\x00" + strconv.FormatBool(webhook.Canonical) + "\x00" + string(webhook.Format)
+		if deliveredBy, ok := queued[dedupeKey]; ok && s.dedupeDeliveries {
+			s.recordDedupedWebhookEvent(webhook.ID, eventType, job.JobID, deliveredBy)
+			continue
+		}
+
+		payload := string(payloadBytes)
+		switch {
+		case webhook.Format == models.WebhookFormatForm:
+			if formBytes == nil {
+				s.recordFailedWebhookEvent(webhook.ID, eventType, job.JobID, "failed to form-encode payload")
+				continue
+			}
+			payload = string(formBytes)
+		case webhook.Canonical:
+			if canonicalBytes == nil {
+				s.recordFailedWebhookEvent(webhook.ID, eventType, job.JobID, "failed to canonicalize payload")
+				continue
+			}
+			payload = string(canonicalBytes)
+		}
+
+		webhookEvent := models.WebhookEvent{
+			WebhookID: webhook.ID,
+			EventType: eventType,
+			JobID:     job.JobID,
+			Payload:   payload,
+			Format:    webhook.Format,
+			Ephemeral: job.Ephemeral,
+		}
+		if err := s.enqueueWebhookEvent(&webhookEvent); err != nil {
+			log.WithError(err).WithField("webhook_id", webhook.ID).Error("Failed to queue webhook event")
+			continue
+		}
+		queued[dedupeKey] = webhookEvent.ID
 	}
 
 	return nil
 }
 
-// sendWebhookEventAsync sends a webhook event asynchronously with retries
-func (s *WebhookService) sendWebhookEventAsync(webhook models.Webhook, payload models.JobWebhookPayload, jobID string) {
-	// Create webhook event record
-	webhookEvent := models.WebhookEvent{
-		WebhookID:    webhook.ID,
-		EventType:    payload.Event,
-		JobID:        jobID,
-		AttemptCount: 0,
-	}
-
-	// Serialize payload
+// marshalWebhookPayload serializes an event payload. On failure the event is recorded as failed
+// against each webhook, so it shows up in the events list and dead letters instead of vanishing.
+func (s *WebhookService) marshalWebhookPayload(payload interface{}, webhooks []models.Webhook, eventType models.WebhookEventType, jobID string) ([]byte, error) {
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
-		log.WithError(err).Error("Failed to marshal webhook payload")
-		return
+		log.WithError(err).WithField("job_id", jobID).Error("Failed to marshal webhook payload")
+		for _, webhook := range webhooks {
+			s.recordFailedWebhookEvent(webhook.ID, eventType, jobID, fmt.Sprintf("failed to marshal payload: %v", err))
+		}
+		return nil, err
 	}
-	webhookEvent.Payload = string(payloadBytes)
+	return payloadBytes, nil
+}
 
-	// Save event record
-	err = s.dbService.Create(&webhookEvent)
+// RecordWebhookEventFailure records a failed event for every webhook subscribed to eventType,
+// for when the job's payload can't be built at all
+func (s *WebhookService) RecordWebhookEventFailure(jobID string, clerkUserID string, eventType models.WebhookEventType, reason string) {
+	subscribedWebhooks, err := s.subscribedWebhooks(clerkUserID, eventType)
 	if err != nil {
-		log.WithError(err).Error("Failed to create webhook event record")
 		return
 	}
-
-	// Send webhook with retries
-	s.sendWebhookWithRetries(&webhookEvent, webhook, payloadBytes)
+	for _, webhook := range subscribedWebhooks {
+		s.recordFailedWebhookEvent(webhook.ID, eventType, jobID, reason)
+	}
 }
 
-// sendWebhookWithRetries sends a webhook with exponential backoff retries
-func (s *WebhookService) sendWebhookWithRetries(webhookEvent *models.WebhookEvent, webhook models.Webhook, payloadBytes []byte) {
-	maxRetries := 3
-	baseDelay := time.Second * 2
+// subscribedWebhooks finds the user's active webhooks subscribed to an event type. When
+// WEBHOOK_REQUIRE_VERIFICATION is set, unverified webhooks are skipped.
+func (s *WebhookService) subscribedWebhooks(clerkUserID string, eventType models.WebhookEventType) ([]models.Webhook, error) {
+	query := s.dbService.GetDB().Where("clerk_user_id = ? AND is_active = ?", clerkUserID, true)
+	if s.requireVerification {
+		query = query.Where("verified = ?", true)
+	}
 
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		webhookEvent.AttemptCount = attempt + 1
+	var webhooks []models.Webhook
+	err := query.Find(&webhooks).Error
+	if err != nil {
+		log.WithError(err).Error("Failed to fetch webhooks for user")
+		return nil, err
+	}
 
-		// Create HTTP request
-		req, err := http.NewRequest("POST", webhook.URL, bytes.NewBuffer(payloadBytes))
-		if err != nil {
-			log.WithError(err).Error("Failed to create webhook request")
+	// Filter webhooks by event type
+	var subscribedWebhooks []models.Webhook
+	for _, webhook := range webhooks {
+		for _, event := range webhook.Events {
+			if event == eventType {
+				subscribedWebhooks = append(subscribedWebhooks, webhook)
+				break
+			}
+		}
+	}
+	return subscribedWebhooks, nil
+}
+
+// loadWebhookDefaultEvents reads WEBHOOK_DEFAULT_EVENTS, dropping event types that don't exist so
+// a typo can't subscribe new webhooks to events that never fire
+func loadWebhookDefaultEvents() models.WebhookEventTypes {
+	var events models.WebhookEventTypes
+	for _, event := range config.GetEnvList("WEBHOOK_DEFAULT_EVENTS") {
+		eventType := models.WebhookEventType(event)
+		if err := validateEventTypes(models.WebhookEventTypes{eventType}); err != nil {
+			log.WithError(err).Warn("Ignoring invalid WEBHOOK_DEFAULT_EVENTS entry")
 			continue
 		}
+		events = append(events, eventType)
+	}
+	return events
+}
 
-		// Set headers
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("User-Agent", "Ignis-Webhooks/1.0")
-		req.Header.Set("X-Webhook-Event", string(webhookEvent.EventType))
-		req.Header.Set("X-Webhook-Delivery", fmt.Sprintf("%d", webhookEvent.ID))
+// validateURLFormat rejects webhook URLs that aren't plain http(s) URLs or that name one of this
+// service's own hostnames. It doesn't resolve the host.
+func (s *WebhookService) validateURLFormat(rawURL string) (*url.URL, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, invalidInput("invalid webhook URL: %w", err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, invalidInput("webhook URL must use http or https")
+	}
 
-		// Add HMAC signature if secret is provided
-		if webhook.Secret != "" {
-			signature := s.generateHMACSignature(payloadBytes, webhook.Secret)
-			req.Header.Set("X-Webhook-Signature", "sha256="+signature)
-		}
+	host := parsed.Hostname()
+	if host == "" {
+		return nil, invalidInput("webhook URL must include a host")
+	}
+	if s.isSelfHost(host) {
+		return nil, invalidInput("webhook URL must not point to this service")
+	}
+	return parsed, nil
+}
 
-		// Send request
-		resp, err := s.httpClient.Do(req)
-		if err != nil {
-			log.WithFields(log.Fields{
-				"webhook_id": webhook.ID,
-				"attempt":    attempt + 1,
-				"error":      err.Error(),
-			}).Warn("Webhook delivery failed")
-
-			// Update event record with error
-			webhookEvent.Response = err.Error()
-			s.dbService.Update(webhookEvent)
-
-			// Wait before retry
-			if attempt < maxRetries-1 {
-				delay := time.Duration(attempt+1) * baseDelay
-				time.Sleep(delay)
-			}
-			continue
-		}
+// validateURL checks a URL about to be requested, such as a redirect target: on top of
+// validateURLFormat it rejects hosts that resolve to loopback, private, link-local or otherwise
+// internal addresses, or back to this service
+func (s *WebhookService) validateURL(rawURL string) error {
+	parsed, err := s.validateURLFormat(rawURL)
+	if err != nil {
+		return err
+	}
 
-		// Read response
-		var responseBody bytes.Buffer
-		if resp.Body != nil {
-			responseBody.ReadFrom(resp.Body)
-			resp.Body.Close()
+	host := parsed.Hostname()
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		ips, err = net.LookupIP(host)
+		if err != nil && !s.allowPrivateNetworks {
+			return invalidInput("webhook host %s could not be resolved", host)
 		}
+	}
 
-		// Update event record
-		webhookEvent.StatusCode = resp.StatusCode
-		webhookEvent.Response = responseBody.String()
-
-		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-			// Success
-			webhookEvent.Delivered = true
-			s.dbService.Update(webhookEvent)
+	// Private networks may be allowed for local development, where the API itself is one
+	if s.isSelfAddress(ips, parsed) {
+		return invalidInput("webhook URL must not point to this service")
+	}
+	if s.allowPrivateNetworks {
+		return nil
+	}
 
-			log.WithFields(log.Fields{
-				"webhook_id":  webhook.ID,
-				"status_code": resp.StatusCode,
-				"attempt":     attempt + 1,
-			}).Info("Webhook delivered successfully")
-			return
+	for _, ip := range ips {
+		if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+			ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+			return invalidInput("webhook URL must not point to an internal address")
 		}
+	}
 
-		// Log failure
-		log.WithFields(log.Fields{
-			"webhook_id":  webhook.ID,
-			"status_code": resp.StatusCode,
-			"attempt":     attempt + 1,
-			"response":    responseBody.String(),
-		}).Warn("Webhook delivery failed with non-2xx status")
+	return nil
+}
 
-		s.dbService.Update(webhookEvent)
+// loadWebhookSelfHosts reads WEBHOOK_SELF_HOSTS, the public hostnames this service is reachable
+// at, plus the host of PUBLIC_BASE_URL. Entries may start with "*." to cover every subdomain.
+func loadWebhookSelfHosts() []string {
+	selfHosts := config.GetEnvList("WEBHOOK_SELF_HOSTS")
+	if baseURL, err := url.Parse(config.GetEnv("PUBLIC_BASE_URL", "")); err == nil && baseURL.Hostname() != "" {
+		selfHosts = append(selfHosts, baseURL.Hostname())
+	}
 
-		// Wait before retry
-		if attempt < maxRetries-1 {
-			delay := time.Duration(attempt+1) * baseDelay
-			time.Sleep(delay)
+	var hosts []string
+	for _, host := range selfHosts {
+		if host = strings.TrimSuffix(strings.ToLower(host), "."); host != "" {
+			hosts = append(hosts, host)
 		}
 	}
+	return hosts
+}
 
-	// All retries failed, schedule for later retry
-	nextRetry := time.Now().Add(time.Hour) // Retry after 1 hour
-	webhookEvent.NextRetryAt = &nextRetry
-	s.dbService.Update(webhookEvent)
+// isSelfHost reports whether host is one of this service's own hostnames, so webhooks can't
+// loop deliveries back into the API
+func (s *WebhookService) isSelfHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	for _, self := range s.selfHosts {
+		if suffix, ok := strings.CutPrefix(self, "*."); ok {
+			if host == suffix || strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+			continue
+		}
+		if host == self {
+			return true
+		}
+	}
+	return false
+}
 
-	log.WithFields(log.Fields{
-		"webhook_id": webhook.ID,
-		"attempts":   maxRetries,
-	}).Error("Webhook delivery failed after all retries")
+// isSelfAddress reports whether a webhook URL resolves to one of this machine's own addresses on
+// the port the API listens on (PORT), i.e. would be delivered straight back to the API
+func (s *WebhookService) isSelfAddress(ips []net.IP, target *url.URL) bool {
+	port := target.Port()
+	if port == "" {
+		port = "80"
+		if target.Scheme == "https" {
+			port = "443"
+		}
+	}
+	if port != config.GetEnv("PORT", "8080") {
+		return false
+	}
+
+	localAddrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return false
+	}
+	for _, ip := range ips {
+		if ip == nil {
+			continue
+		}
+		if ip.IsLoopback() || ip.IsUnspecified() {
+			return true
+		}
+		for _, addr := range localAddrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // generateHMACSignature generates HMAC SHA256 signature for webhook payload
@@ -320,6 +610,71 @@ func (s *WebhookService) generateHMACSignature(payload []byte, secret string) st
 	return hex.EncodeToString(h.Sum(nil))
 }
 
+// canonicalJSON re-encodes a JSON document with object keys sorted, no insignificant whitespace,
+// and <, > and & left unescaped, so receivers that parse and re-serialize the same way get
+// identical bytes. Numbers are kept exactly as written.
+func canonicalJSON(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	// encoding/json writes map keys in sorted order
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// formEncodePayload flattens a JSON payload into application/x-www-form-urlencoded fields for
+// receivers that can't parse JSON. Nested keys are joined with dots (job.job_id) and array
+// elements are indexed (job.tags.0); null values are left out. Fields are sorted by key.
+func formEncodePayload(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	flattenFormValue(form, "", value)
+	return []byte(form.Encode()), nil
+}
+
+// flattenFormValue adds value to form under prefix, recursing into objects and arrays
+func flattenFormValue(form url.Values, prefix string, value interface{}) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			flattenFormValue(form, join(key), child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			flattenFormValue(form, join(strconv.Itoa(i)), child)
+		}
+	case nil:
+	case string:
+		form.Set(prefix, v)
+	default:
+		form.Set(prefix, fmt.Sprint(v))
+	}
+}
+
 // toWebhookResponse converts Webhook model to WebhookResponse
 func (s *WebhookService) toWebhookResponse(webhook models.Webhook) *models.WebhookResponse {
 	return &models.WebhookResponse{
@@ -327,32 +682,57 @@ func (s *WebhookService) toWebhookResponse(webhook models.Webhook) *models.Webho
 		URL:         webhook.URL,
 		Events:      webhook.Events,
 		IsActive:    webhook.IsActive,
+		RateLimit:   webhook.RateLimit,
+		Canonical:   webhook.Canonical,
+		Format:      webhook.Format,
+		RetryPolicy: webhook.RetryPolicy,
+		RetryDelay:  webhook.RetryDelay,
+		MaxAttempts: webhook.MaxAttempts,
+		Verified:    webhook.Verified,
+		VerifiedAt:  webhook.VerifiedAt,
 		ClerkUserID: webhook.ClerkUserID,
 		CreatedAt:   webhook.CreatedAt,
 		UpdatedAt:   webhook.UpdatedAt,
 	}
 }
 
-// GetWebhookEvents retrieves webhook events for a webhook
-func (s *WebhookService) GetWebhookEvents(webhookID uint, clerkUserID string, limit int, offset int) ([]models.WebhookEventResponse, error) {
+// GetWebhookEvents retrieves webhook events for a webhook. With offset pagination the total
+// number of events is returned too; cursor pagination (SinceID) skips the count and returns 0.
+// IDs are assigned before commit, so an event can become visible after one with a higher ID; the
+// cursor only returns events older than eventsCursorLag so a client never moves past a gap.
+func (s *WebhookService) GetWebhookEvents(webhookID uint, clerkUserID string, opts models.WebhookEventListOptions) ([]models.WebhookEventResponse, int64, error) {
 	// First verify webhook belongs to user
 	var webhook models.Webhook
 	err := s.dbService.FindOne(&webhook, "id = ? AND clerk_user_id = ?", webhookID, clerkUserID)
 	if err != nil {
-		return nil, fmt.Errorf("webhook not found")
+		return nil, 0, ErrWebhookNotFound
 	}
 
-	// Get events with pagination
+	orderClause, err := webhookEventOrderClause(opts.Sort, opts.Order)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := s.dbService.GetDB().Model(&models.WebhookEvent{}).Where("webhook_id = ?", webhookID)
+	if opts.DeadLetter {
+		query = query.Where("dead_letter_at IS NOT NULL")
+	}
+
+	// Get events with sorting and pagination
+	var total int64
 	var events []models.WebhookEvent
-	query := "webhook_id = ? ORDER BY created_at DESC LIMIT ? OFFSET ?"
-	err = s.dbService.GetDB().Where(query, webhookID, limit, offset).Find(&events).Error
+	if opts.SinceID != nil {
+		err = query.Where("id > ? AND created_at < ?", *opts.SinceID, time.Now().Add(-s.eventsCursorLag)).Order("id ASC").Limit(opts.Limit).Find(&events).Error
+	} else {
+		total, err = findPage(query, &events, orderClause, opts.Limit, opts.Offset)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch webhook events: %w", err)
+		return nil, 0, fmt.Errorf("failed to fetch webhook events: %w", err)
 	}
 
-	var responses []models.WebhookEventResponse
+	responses := make([]models.WebhookEventResponse, 0, len(events))
 	for _, event := range events {
-		responses = append(responses, models.WebhookEventResponse{
+		response := models.WebhookEventResponse{
 			ID:           event.ID,
 			WebhookID:    event.WebhookID,
 			EventType:    event.EventType,
@@ -360,11 +740,48 @@ func (s *WebhookService) GetWebhookEvents(webhookID uint, clerkUserID string, li
 			Delivered:    event.Delivered,
 			StatusCode:   event.StatusCode,
 			AttemptCount: event.AttemptCount,
+			DurationMs:   event.DurationMs,
 			NextRetryAt:  event.NextRetryAt,
+			DedupedInto:  event.DedupedInto,
+			AckedAt:      event.AckedAt,
+			DeadLetterAt: event.DeadLetterAt,
 			CreatedAt:    event.CreatedAt,
 			UpdatedAt:    event.UpdatedAt,
-		})
+		}
+		if opts.IncludePayload {
+			response.Payload = event.Payload
+		}
+		responses = append(responses, response)
+	}
+
+	return responses, total, nil
+}
+
+// webhookEventOrderClause builds an ORDER BY clause from allowlisted sort inputs only
+func webhookEventOrderClause(sort string, order string) (string, error) {
+	if sort == "" {
+		sort = "created_at"
+	}
+	if order == "" {
+		order = "desc"
+	}
+
+	allowed := false
+	for _, field := range models.WebhookEventSortFields {
+		if field == sort {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return "", invalidInput("invalid sort field: %s", sort)
+	}
+
+	order = strings.ToLower(order)
+	if order != "asc" && order != "desc" {
+		return "", invalidInput("invalid sort order: %s", order)
 	}
 
-	return responses, nil
+	// Tie-break on id so pagination stays stable when sort values repeat
+	return fmt.Sprintf("%s %s, id %s", sort, order, order), nil
 }
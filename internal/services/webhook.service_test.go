@@ -0,0 +1,116 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"ignis/internal/models"
+)
+
+func TestMarshalWebhookPayloadRecordsFailure(t *testing.T) {
+	dbService, written := newDryRunDBService(t)
+	s := &WebhookService{dbService: dbService}
+
+	webhooks := []models.Webhook{{ID: 1}, {ID: 2}}
+	unmarshalable := map[string]interface{}{"job": make(chan int)}
+
+	payloadBytes, err := s.marshalWebhookPayload(unmarshalable, webhooks, models.WebhookEventJobCompleted, "job_123")
+	if err == nil {
+		t.Fatal("expected a marshal error")
+	}
+	if payloadBytes != nil {
+		t.Errorf("expected no payload, got %q", payloadBytes)
+	}
+
+	if len(*written) != len(webhooks) {
+		t.Fatalf("expected %d recorded events, got %d", len(webhooks), len(*written))
+	}
+	for i, record := range *written {
+		event, ok := record.(*models.WebhookEvent)
+		if !ok {
+			t.Fatalf("expected a webhook event, got %T", record)
+		}
+		if event.WebhookID != webhooks[i].ID {
+			t.Errorf("event %d: expected webhook %d, got %d", i, webhooks[i].ID, event.WebhookID)
+		}
+		if event.JobID != "job_123" || event.EventType != models.WebhookEventJobCompleted {
+			t.Errorf("event %d: recorded for %s/%s", i, event.JobID, event.EventType)
+		}
+		if event.Delivered || event.NextRetryAt != nil {
+			t.Errorf("event %d: expected an undelivered event with no retry scheduled", i)
+		}
+		if event.DeadLetterAt == nil {
+			t.Errorf("event %d: expected the event to be dead-lettered", i)
+		}
+		if !strings.HasPrefix(event.Response, "failed to marshal payload:") {
+			t.Errorf("event %d: unexpected response %q", i, event.Response)
+		}
+	}
+}
+
+func TestMarshalWebhookPayloadSucceeds(t *testing.T) {
+	dbService, written := newDryRunDBService(t)
+	s := &WebhookService{dbService: dbService}
+
+	payload := models.JobWebhookPayload{Event: models.WebhookEventJobCompleted, Job: models.JobWebhookResponse{JobID: "job_123"}}
+	payloadBytes, err := s.marshalWebhookPayload(payload, []models.Webhook{{ID: 1}}, models.WebhookEventJobCompleted, "job_123")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(payloadBytes), `"job_id":"job_123"`) {
+		t.Errorf("unexpected payload %s", payloadBytes)
+	}
+	if len(*written) != 0 {
+		t.Errorf("expected nothing recorded, got %d records", len(*written))
+	}
+}
+
+func TestResetVerification(t *testing.T) {
+	tests := []struct {
+		name                string
+		requireVerification bool
+		isActive            bool
+		wantActive          bool
+		wantReverify        bool
+	}{
+		{name: "verification optional", requireVerification: false, isActive: true, wantActive: true, wantReverify: false},
+		{name: "verification required", requireVerification: true, isActive: true, wantActive: false, wantReverify: true},
+		{name: "verification required, already inactive", requireVerification: true, isActive: false, wantActive: false, wantReverify: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &WebhookService{requireVerification: tt.requireVerification}
+			verifiedAt := time.Now()
+			webhook := models.Webhook{URL: "https://example.com/new", Verified: true, VerifiedAt: &verifiedAt, IsActive: tt.isActive}
+
+			s.resetVerification(&webhook)
+
+			if webhook.Verified || webhook.VerifiedAt != nil {
+				t.Errorf("expected verification to be cleared, got verified=%v verified_at=%v", webhook.Verified, webhook.VerifiedAt)
+			}
+			if webhook.IsActive != tt.wantActive {
+				t.Errorf("expected is_active=%v, got %v", tt.wantActive, webhook.IsActive)
+			}
+			if webhook.Reverify != tt.wantReverify {
+				t.Errorf("expected reverify=%v, got %v", tt.wantReverify, webhook.Reverify)
+			}
+		})
+	}
+}
+
+func TestLoadWebhookDefaultEventsDropsUnknown(t *testing.T) {
+	t.Setenv("WEBHOOK_DEFAULT_EVENTS", "job.completed, job.finished ,job.failed")
+
+	events := loadWebhookDefaultEvents()
+	want := models.WebhookEventTypes{models.WebhookEventJobCompleted, models.WebhookEventJobFailed}
+	if len(events) != len(want) {
+		t.Fatalf("expected %v, got %v", want, events)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("expected %v, got %v", want, events)
+		}
+	}
+}
@@ -0,0 +1,537 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ignis/internal/config"
+	"ignis/internal/models"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxWebhookRetryDelay caps the delays computed from a webhook's retry policy, matching the
+// hourly retries of the default schedule
+const maxWebhookRetryDelay = time.Hour
+
+// webhookResponseTruncatedNote is appended to stored response bodies that exceeded WEBHOOK_RESPONSE_MAX_BYTES
+const webhookResponseTruncatedNote = "\n[truncated]"
+
+// webhookDeliveryConfig controls the durable webhook delivery queue
+type webhookDeliveryConfig struct {
+	workers      int           // concurrent deliveries per process
+	pollInterval time.Duration // how often the queue is checked when nothing wakes it
+	claimTimeout time.Duration // how long a claimed event is reserved before another worker may take it
+	quickRetries int           // attempts retried with a short backoff before falling back to hourly retries
+	maxAttempts  int           // attempts after which an event is given up on
+	retryBase    time.Duration // base delay for webhooks with a retry policy that don't set their own
+	rateLimit    int           // default deliveries per minute per webhook; 0 disables pacing
+	maxResponse  int64         // bytes of the receiver's response body kept for diagnostics
+	reconcile    time.Duration // how often events with a successful attempt are marked delivered; 0 disables
+}
+
+// loadWebhookDeliveryConfig reads webhook delivery settings from the environment
+func loadWebhookDeliveryConfig() webhookDeliveryConfig {
+	cfg := webhookDeliveryConfig{
+		workers:      config.GetEnvInt("WEBHOOK_WORKERS", 4),
+		pollInterval: config.GetEnvDuration("WEBHOOK_POLL_INTERVAL", 2*time.Second),
+		claimTimeout: config.GetEnvDuration("WEBHOOK_CLAIM_TIMEOUT", 2*time.Minute),
+		quickRetries: config.GetEnvInt("WEBHOOK_MAX_RETRIES", 3),
+		maxAttempts:  config.GetEnvInt("WEBHOOK_MAX_ATTEMPTS", 6),
+		retryBase:    config.GetEnvDuration("WEBHOOK_RETRY_BASE_DELAY", 2*time.Second),
+		rateLimit:    config.GetEnvInt("WEBHOOK_DELIVERY_RATE_LIMIT", 600),
+		maxResponse:  int64(config.GetEnvInt("WEBHOOK_RESPONSE_MAX_BYTES", 8*1024)),
+		reconcile:    config.GetEnvDuration("WEBHOOK_RECONCILE_INTERVAL", 5*time.Minute),
+	}
+	if cfg.workers < 1 {
+		cfg.workers = 1
+	}
+	if cfg.maxAttempts < cfg.quickRetries {
+		cfg.maxAttempts = cfg.quickRetries
+	}
+	if cfg.maxResponse < 0 {
+		cfg.maxResponse = 0
+	}
+	if cfg.retryBase <= 0 {
+		cfg.retryBase = 2 * time.Second
+	}
+	return cfg
+}
+
+// nextRetryDelay returns how long to wait after the given failed attempt
+func (c webhookDeliveryConfig) nextRetryDelay(attempt int) time.Duration {
+	if attempt < c.quickRetries {
+		return time.Duration(attempt) * 2 * time.Second
+	}
+	return time.Hour
+}
+
+// retryDelayFor returns how long to wait after the given failed attempt to the webhook, following
+// its retry policy or, without one, the default schedule
+func (c webhookDeliveryConfig) retryDelayFor(webhook models.Webhook, attempt int) time.Duration {
+	base := time.Duration(webhook.RetryDelay) * time.Second
+	if base <= 0 {
+		base = c.retryBase
+	}
+
+	var delay time.Duration
+	switch webhook.RetryPolicy {
+	case models.WebhookRetryFixed:
+		delay = base
+	case models.WebhookRetryLinear:
+		delay = base * time.Duration(attempt)
+	case models.WebhookRetryExponential:
+		delay = base
+		for i := 1; i < attempt && delay < maxWebhookRetryDelay; i++ {
+			delay *= 2
+		}
+	default:
+		return c.nextRetryDelay(attempt)
+	}
+
+	if delay > maxWebhookRetryDelay {
+		delay = maxWebhookRetryDelay
+	}
+	return delay
+}
+
+// maxAttemptsFor returns how many attempts an event to the webhook gets before it is given up on
+func (c webhookDeliveryConfig) maxAttemptsFor(webhook models.Webhook) int {
+	if webhook.MaxAttempts > 0 {
+		return webhook.MaxAttempts
+	}
+	return c.maxAttempts
+}
+
+// enqueueWebhookEvent stores an event as due for delivery and wakes the delivery workers.
+// The row is the queue entry, so a restart never loses a pending delivery.
+func (s *WebhookService) enqueueWebhookEvent(webhookEvent *models.WebhookEvent) error {
+	now := time.Now()
+	webhookEvent.NextRetryAt = &now
+
+	if err := s.dbService.Create(webhookEvent); err != nil {
+		return fmt.Errorf("failed to create webhook event record: %w", err)
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+		// A wake-up is already pending
+	}
+	return nil
+}
+
+// recordFailedWebhookEvent stores an event that could not be queued for delivery, e.g. because its
+// payload couldn't be built. It is never retried and goes straight to the dead letters.
+func (s *WebhookService) recordFailedWebhookEvent(webhookID uint, eventType models.WebhookEventType, jobID string, reason string) {
+	now := time.Now()
+	webhookEvent := models.WebhookEvent{
+		WebhookID:    webhookID,
+		EventType:    eventType,
+		JobID:        jobID,
+		Response:     reason,
+		DeadLetterAt: &now,
+	}
+	if err := s.dbService.Create(&webhookEvent); err != nil {
+		log.WithError(err).WithField("webhook_id", webhookID).Error("Failed to record failed webhook event")
+	}
+}
+
+// recordDedupedWebhookEvent stores an event that was not sent because another webhook with the
+// same URL and secret is already receiving the same payload in event deliveredBy
+func (s *WebhookService) recordDedupedWebhookEvent(webhookID uint, eventType models.WebhookEventType, jobID string, deliveredBy uint) {
+	webhookEvent := models.WebhookEvent{
+		WebhookID:   webhookID,
+		EventType:   eventType,
+		JobID:       jobID,
+		Response:    fmt.Sprintf("deduplicated: delivered by event %d", deliveredBy),
+		DedupedInto: &deliveredBy,
+	}
+	if err := s.dbService.Create(&webhookEvent); err != nil {
+		log.WithError(err).WithField("webhook_id", webhookID).Error("Failed to record deduplicated webhook event")
+	}
+}
+
+// runDeliveryQueue claims due webhook events and hands them to a pool of delivery workers.
+// It runs for the lifetime of the process and picks up anything left pending by a previous one.
+func (s *WebhookService) runDeliveryQueue() {
+	events := make(chan models.WebhookEvent)
+	for i := 0; i < s.delivery.workers; i++ {
+		go func() {
+			for event := range events {
+				s.deliverWebhookEvent(event)
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(s.delivery.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		claimed, err := s.claimDueWebhookEvents(s.delivery.workers)
+		if err != nil {
+			log.WithError(err).Error("Failed to claim webhook events")
+		}
+		for _, event := range claimed {
+			events <- event
+		}
+
+		// Keep draining while there is a backlog; otherwise wait for new events or the next poll
+		if len(claimed) == s.delivery.workers {
+			continue
+		}
+		select {
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// claimDueWebhookEvents reserves up to limit due events for this process. Rows locked by
+// another instance are skipped, so several API servers can share the queue.
+func (s *WebhookService) claimDueWebhookEvents(limit int) ([]models.WebhookEvent, error) {
+	var events []models.WebhookEvent
+
+	err := s.dbService.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("delivered = ? AND next_retry_at <= ?", false, now).
+			Where("claimed_until IS NULL OR claimed_until < ?", now).
+			Order("next_retry_at ASC, id ASC").
+			Limit(limit).
+			Find(&events).Error
+		if err != nil || len(events) == 0 {
+			return err
+		}
+
+		ids := make([]uint, len(events))
+		for i, event := range events {
+			ids[i] = event.ID
+		}
+		return tx.Model(&models.WebhookEvent{}).
+			Where("id IN ?", ids).
+			Update("claimed_until", now.Add(s.delivery.claimTimeout)).Error
+	})
+
+	return events, err
+}
+
+// deliverWebhookEvent makes one delivery attempt for a claimed event and records the
+// outcome, scheduling the next attempt on failure
+func (s *WebhookService) deliverWebhookEvent(webhookEvent models.WebhookEvent) {
+	var webhook models.Webhook
+	if err := s.dbService.GetByID(&webhook, webhookEvent.WebhookID); err != nil || !webhook.IsActive {
+		// The webhook was deleted or disabled after the event was queued
+		s.deadLetterWebhookEvent(&webhookEvent, "webhook no longer active")
+		return
+	}
+
+	if delay := s.deliveryDeferral(webhook); delay > 0 {
+		// Over the webhook's delivery rate: push the event back without spending an attempt
+		nextRetry := time.Now().Add(delay)
+		webhookEvent.NextRetryAt = &nextRetry
+		webhookEvent.ClaimedUntil = nil
+		if err := s.dbService.Update(&webhookEvent); err != nil {
+			log.WithError(err).WithField("event_id", webhookEvent.ID).Error("Failed to defer webhook event")
+		}
+		log.WithFields(log.Fields{
+			"webhook_id": webhook.ID,
+			"event_id":   webhookEvent.ID,
+			"delay":      delay.String(),
+		}).Debug("Webhook delivery deferred by rate limit")
+		return
+	}
+
+	logFields := log.Fields{
+		"webhook_id": webhook.ID,
+		"event_id":   webhookEvent.ID,
+		"attempt":    webhookEvent.AttemptCount + 1,
+	}
+
+	// A previous attempt may have succeeded without the event being marked delivered, e.g. if the
+	// process stopped in between; don't send it again
+	if delivered, err := s.hasSuccessfulAttempt(webhookEvent.ID); err != nil {
+		log.WithError(err).WithFields(logFields).Warn("Failed to check previous webhook attempts")
+	} else if delivered {
+		webhookEvent.Delivered = true
+		s.finishWebhookEvent(&webhookEvent, "")
+		log.WithFields(logFields).Warn("Webhook event already delivered by an earlier attempt")
+		return
+	}
+
+	webhookEvent.AttemptCount++
+	start := time.Now()
+	statusCode, responseBody, err := s.postWebhookEvent(webhook, webhookEvent)
+	webhookEvent.DurationMs = time.Since(start).Milliseconds()
+	webhookEvent.StatusCode = statusCode
+	s.recordWebhookAttempt(webhookEvent, err)
+	if err != nil {
+		webhookEvent.Response = err.Error()
+	} else {
+		webhookEvent.Response = responseBody
+	}
+
+	switch {
+	case err == nil && statusCode >= 200 && statusCode < 300:
+		webhookEvent.Delivered = true
+		s.finishWebhookEvent(&webhookEvent, "")
+		log.WithFields(logFields).WithField("status_code", statusCode).Info("Webhook delivered successfully")
+		return
+
+	case errors.Is(err, errWebhookRedirectBlocked):
+		// A disallowed redirect won't change between attempts, so record it and stop
+		s.deadLetterWebhookEvent(&webhookEvent, "")
+		log.WithFields(logFields).WithField("error", err.Error()).Warn("Webhook delivery failed due to a disallowed redirect")
+		return
+
+	case err != nil:
+		log.WithFields(logFields).WithField("error", err.Error()).Warn("Webhook delivery failed")
+
+	default:
+		log.WithFields(logFields).WithFields(log.Fields{
+			"status_code": statusCode,
+			"response":    responseBody,
+		}).Warn("Webhook delivery failed with non-2xx status")
+	}
+
+	if webhookEvent.AttemptCount >= s.delivery.maxAttemptsFor(webhook) {
+		s.deadLetterWebhookEvent(&webhookEvent, "")
+		log.WithFields(logFields).Error("Webhook delivery failed after all retries")
+		return
+	}
+
+	nextRetry := time.Now().Add(s.delivery.retryDelayFor(webhook, webhookEvent.AttemptCount))
+	webhookEvent.NextRetryAt = &nextRetry
+	webhookEvent.ClaimedUntil = nil
+	if err := s.dbService.Update(&webhookEvent); err != nil {
+		log.WithError(err).WithFields(logFields).Error("Failed to schedule webhook retry")
+	}
+}
+
+// recordWebhookAttempt stores the outcome of the attempt just made on an event
+func (s *WebhookService) recordWebhookAttempt(webhookEvent models.WebhookEvent, deliveryErr error) {
+	attempt := models.WebhookEventAttempt{
+		EventID:    webhookEvent.ID,
+		Attempt:    webhookEvent.AttemptCount,
+		StatusCode: webhookEvent.StatusCode,
+		DurationMs: webhookEvent.DurationMs,
+	}
+	if deliveryErr != nil {
+		attempt.Error = deliveryErr.Error()
+		if len(attempt.Error) > 500 {
+			attempt.Error = attempt.Error[:500]
+		}
+	}
+	if err := s.dbService.Create(&attempt); err != nil {
+		log.WithError(err).WithField("event_id", webhookEvent.ID).Error("Failed to record webhook attempt")
+	}
+}
+
+// hasSuccessfulAttempt reports whether any recorded attempt of the event got a 2xx response
+func (s *WebhookService) hasSuccessfulAttempt(eventID uint) (bool, error) {
+	count, err := s.dbService.Count(&models.WebhookEventAttempt{},
+		"event_id = ? AND error = '' AND status_code >= 200 AND status_code < 300", eventID)
+	return count > 0, err
+}
+
+// runDeliveryReconciler periodically marks undelivered events that have a successful attempt on
+// record as delivered, so the retry worker doesn't send them twice
+func (s *WebhookService) runDeliveryReconciler() {
+	if s.delivery.reconcile <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.delivery.reconcile)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		reconciled, err := s.reconcileDeliveredEvents()
+		if err != nil {
+			log.WithError(err).Error("Failed to reconcile webhook deliveries")
+			continue
+		}
+		if reconciled > 0 {
+			log.WithField("count", reconciled).Warn("Marked webhook events delivered from their attempt history")
+		}
+	}
+}
+
+// reconcileDeliveredEvents sets delivered on events whose flag was lost after a successful attempt
+func (s *WebhookService) reconcileDeliveredEvents() (int64, error) {
+	successful := s.dbService.GetDB().Model(&models.WebhookEventAttempt{}).
+		Select("event_id").
+		Where("error = '' AND status_code >= 200 AND status_code < 300")
+
+	result := s.dbService.GetDB().Model(&models.WebhookEvent{}).
+		Where("delivered = ? AND id IN (?)", false, successful).
+		Updates(map[string]interface{}{
+			"delivered":     true,
+			"next_retry_at": nil,
+			"claimed_until": nil,
+		})
+	return result.RowsAffected, result.Error
+}
+
+// deliveryDeferral returns how long to postpone a delivery to stay within the webhook's
+// rate limit, or zero if it may be sent now
+func (s *WebhookService) deliveryDeferral(webhook models.Webhook) time.Duration {
+	limit := webhook.RateLimit
+	if limit <= 0 {
+		limit = s.delivery.rateLimit
+	}
+	if limit <= 0 || s.rateLimiter == nil {
+		return 0
+	}
+
+	allowed, err := s.rateLimiter.Allow(GetWebhookDeliveryRateLimitKey(strconv.Itoa(int(webhook.ID)), limit), limit, time.Minute)
+	if err != nil {
+		// Don't hold deliveries hostage to a limiter outage
+		log.WithError(err).WithField("webhook_id", webhook.ID).Warn("Webhook rate limiter error")
+		return 0
+	}
+	if allowed {
+		return 0
+	}
+
+	// Retry after roughly one slot's worth of the window
+	delay := time.Minute / time.Duration(limit)
+	if delay < time.Second {
+		delay = time.Second
+	}
+	return delay
+}
+
+// deadLetterWebhookEvent finishes an event that won't be delivered, marking it as a dead letter so
+// it can be listed apart from events still being retried
+func (s *WebhookService) deadLetterWebhookEvent(webhookEvent *models.WebhookEvent, reason string) {
+	now := time.Now()
+	webhookEvent.DeadLetterAt = &now
+	s.finishWebhookEvent(webhookEvent, reason)
+}
+
+// finishWebhookEvent takes an event off the queue, optionally recording why. Payloads of
+// ephemeral jobs aren't kept once there is nothing left to deliver.
+func (s *WebhookService) finishWebhookEvent(webhookEvent *models.WebhookEvent, reason string) {
+	if reason != "" {
+		webhookEvent.Response = reason
+	}
+	if webhookEvent.Ephemeral {
+		webhookEvent.Payload = ""
+	}
+	webhookEvent.NextRetryAt = nil
+	webhookEvent.ClaimedUntil = nil
+	if err := s.dbService.Update(webhookEvent); err != nil {
+		log.WithError(err).WithField("event_id", webhookEvent.ID).Error("Failed to update webhook event")
+	}
+}
+
+// postWebhookEvent sends the stored payload to the webhook URL, returning the status code and response body
+func (s *WebhookService) postWebhookEvent(webhook models.Webhook, webhookEvent models.WebhookEvent) (int, string, error) {
+	payloadBytes := []byte(webhookEvent.Payload)
+
+	req, err := http.NewRequest("POST", webhook.URL, bytes.NewBuffer(payloadBytes))
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to create webhook request: %w", err)
+	}
+
+	// Set headers
+	req.Header.Set("Content-Type", webhookEvent.Format.ContentType())
+	req.Header.Set("User-Agent", "Ignis-Webhooks/1.0")
+	req.Header.Set("X-Webhook-Event", string(webhookEvent.EventType))
+	req.Header.Set("X-Webhook-Delivery", fmt.Sprintf("%d", webhookEvent.ID))
+
+	// Add HMAC signature if secret is provided
+	if webhook.Secret != "" {
+		signature := s.generateHMACSignature(payloadBytes, webhook.Secret)
+		req.Header.Set("X-Webhook-Signature", "sha256="+signature)
+	}
+	// During a secret rotation's grace period, also sign with the old secret
+	if previous := activePreviousSecret(webhook); previous != "" {
+		req.Header.Set("X-Webhook-Signature-Previous", "sha256="+s.generateHMACSignature(payloadBytes, previous))
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	// Keep only a bounded snippet so a huge response can't exhaust memory or bloat the events table.
+	// Reading one extra byte tells us whether anything was cut off.
+	var responseBody bytes.Buffer
+	responseBody.ReadFrom(io.LimitReader(resp.Body, s.delivery.maxResponse+1))
+
+	response := responseBody.String()
+	if int64(responseBody.Len()) > s.delivery.maxResponse {
+		response = strings.ToValidUTF8(response[:s.delivery.maxResponse], "") + webhookResponseTruncatedNote
+	}
+
+	return resp.StatusCode, response, nil
+}
+
+// AcknowledgeWebhookEvents marks undelivered events of a user's webhook as delivered because the
+// receiver processed them some other way, e.g. through the events catch-up listing, so they
+// aren't retried. Events claimed by a delivery worker at that moment are left alone.
+func (s *WebhookService) AcknowledgeWebhookEvents(webhookID uint, clerkUserID string, eventIDs []uint) (*models.WebhookEventAckResult, error) {
+	var webhook models.Webhook
+	if err := s.dbService.FindOne(&webhook, "id = ? AND clerk_user_id = ?", webhookID, clerkUserID); err != nil {
+		return nil, ErrWebhookNotFound
+	}
+
+	result := &models.WebhookEventAckResult{Acknowledged: []uint{}, NotAcknowledged: []uint{}}
+	err := s.dbService.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		var ackable []uint
+		err := tx.Model(&models.WebhookEvent{}).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("webhook_id = ? AND id IN ? AND delivered = ?", webhookID, eventIDs, false).
+			Where("claimed_until IS NULL OR claimed_until < ?", now).
+			Pluck("id", &ackable).Error
+		if err != nil || len(ackable) == 0 {
+			return err
+		}
+
+		err = tx.Model(&models.WebhookEvent{}).
+			Where("id IN ?", ackable).
+			Updates(map[string]interface{}{
+				"delivered":     true,
+				"acked_at":      now,
+				"next_retry_at": nil,
+				"claimed_until": nil,
+			}).Error
+		if err != nil {
+			return err
+		}
+		result.Acknowledged = ackable
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to acknowledge webhook events: %w", err)
+	}
+
+	acknowledged := make(map[uint]bool, len(result.Acknowledged))
+	for _, id := range result.Acknowledged {
+		acknowledged[id] = true
+	}
+	for _, id := range eventIDs {
+		if !acknowledged[id] {
+			result.NotAcknowledged = append(result.NotAcknowledged, id)
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"webhook_id":    webhookID,
+		"clerk_user_id": clerkUserID,
+		"acknowledged":  len(result.Acknowledged),
+	}).Info("Webhook events acknowledged")
+
+	return result, nil
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"ignis/internal/models"
+)
+
+const (
+	// maxLatencySamples bounds how many recent deliveries a latency histogram is built from
+	maxLatencySamples = 1000
+)
+
+// webhookLatencyBoundsMs are the upper bounds of the latency histogram buckets
+var webhookLatencyBoundsMs = []int64{100, 250, 500, 1000, 2500, 5000, 10000}
+
+// GetWebhookLatency builds a histogram of how long the webhook's receiver took to accept its
+// most recent successful deliveries since a given time
+func (s *WebhookService) GetWebhookLatency(webhookID uint, clerkUserID string, since time.Time) (*models.WebhookLatencyHistogram, error) {
+	var webhook models.Webhook
+	if err := s.dbService.FindOne(&webhook, "id = ? AND clerk_user_id = ?", webhookID, clerkUserID); err != nil {
+		return nil, ErrWebhookNotFound
+	}
+
+	var durations []int64
+	err := s.dbService.GetDB().Model(&models.WebhookEvent{}).
+		Where("webhook_id = ? AND delivered = ? AND duration_ms > 0 AND updated_at >= ?", webhookID, true, since).
+		Order("id DESC").
+		Limit(maxLatencySamples).
+		Pluck("duration_ms", &durations).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch delivery durations: %w", err)
+	}
+
+	histogram := &models.WebhookLatencyHistogram{
+		WebhookID:  webhookID,
+		Since:      since,
+		Deliveries: len(durations),
+		TimeoutMs:  s.httpClient.Timeout.Milliseconds(),
+		Buckets:    make([]models.WebhookLatencyBucket, 0, len(webhookLatencyBoundsMs)+1),
+	}
+	for i := range webhookLatencyBoundsMs {
+		histogram.Buckets = append(histogram.Buckets, models.WebhookLatencyBucket{UpperMs: &webhookLatencyBoundsMs[i]})
+	}
+	histogram.Buckets = append(histogram.Buckets, models.WebhookLatencyBucket{})
+
+	var total int64
+	for _, duration := range durations {
+		total += duration
+		if duration > histogram.MaxMs {
+			histogram.MaxMs = duration
+		}
+
+		bucket := len(webhookLatencyBoundsMs)
+		for i, bound := range webhookLatencyBoundsMs {
+			if duration <= bound {
+				bucket = i
+				break
+			}
+		}
+		histogram.Buckets[bucket].Count++
+	}
+	if len(durations) > 0 {
+		histogram.AvgMs = total / int64(len(durations))
+	}
+
+	return histogram, nil
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"ignis/internal/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// generateWebhookSecret returns a random hex HMAC secret for a webhook
+func generateWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
+
+// RotateWebhookSecret replaces a webhook's HMAC secret with a generated one, which is returned
+// only here. For WEBHOOK_SECRET_ROTATION_GRACE afterwards, deliveries are also signed with the
+// old secret so receivers can switch over without rejecting events.
+func (s *WebhookService) RotateWebhookSecret(id uint, clerkUserID string) (*models.WebhookSecretRotateResponse, error) {
+	var webhook models.Webhook
+	if err := s.dbService.FindOne(&webhook, "id = ? AND clerk_user_id = ?", id, clerkUserID); err != nil {
+		return nil, ErrWebhookNotFound
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.PreviousSecret = ""
+	webhook.PreviousSecretExpiresAt = nil
+	if webhook.Secret != "" && s.secretRotationGrace > 0 {
+		expiresAt := time.Now().Add(s.secretRotationGrace)
+		webhook.PreviousSecret = webhook.Secret
+		webhook.PreviousSecretExpiresAt = &expiresAt
+	}
+	webhook.Secret = secret
+
+	if err := s.dbService.Update(&webhook); err != nil {
+		return nil, fmt.Errorf("failed to rotate webhook secret: %w", err)
+	}
+
+	log.WithFields(log.Fields{
+		"webhook_id":    id,
+		"clerk_user_id": clerkUserID,
+	}).Info("Webhook secret rotated")
+
+	return &models.WebhookSecretRotateResponse{
+		WebhookResponse:         *s.toWebhookResponse(webhook),
+		Secret:                  secret,
+		PreviousSecretExpiresAt: webhook.PreviousSecretExpiresAt,
+	}, nil
+}
+
+// activePreviousSecret returns the secret a webhook had before its last rotation while it is
+// still within the rotation grace period, and "" otherwise
+func activePreviousSecret(webhook models.Webhook) string {
+	if webhook.PreviousSecret == "" || webhook.PreviousSecretExpiresAt == nil || time.Now().After(*webhook.PreviousSecretExpiresAt) {
+		return ""
+	}
+	return webhook.PreviousSecret
+}
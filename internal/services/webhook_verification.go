@@ -0,0 +1,143 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"ignis/internal/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// webhookVerificationEvent is sent in the X-Webhook-Event header of verification challenges
+const webhookVerificationEvent = "webhook.verification"
+
+// ErrWebhookVerificationFailed is returned when a webhook endpoint doesn't echo its challenge
+var ErrWebhookVerificationFailed = errors.New("webhook verification failed")
+
+// VerifyWebhook proves the user controls a webhook's endpoint by posting a random challenge to it.
+// The endpoint must answer with a 2xx status and echo the challenge, either as the response body
+// (plain, or as {"challenge": "..."}) or in an X-Webhook-Challenge response header.
+func (s *WebhookService) VerifyWebhook(id uint, clerkUserID string) (*models.WebhookResponse, error) {
+	var webhook models.Webhook
+	if err := s.dbService.FindOne(&webhook, "id = ? AND clerk_user_id = ?", id, clerkUserID); err != nil {
+		return nil, ErrWebhookNotFound
+	}
+
+	if err := s.verifyWebhook(&webhook); err != nil {
+		return nil, err
+	}
+	return s.toWebhookResponse(webhook), nil
+}
+
+// verifyWebhook sends the verification challenge to webhook's URL and marks it verified if answered
+func (s *WebhookService) verifyWebhook(webhook *models.Webhook) error {
+	if webhook.Verified {
+		return nil
+	}
+
+	challenge, err := generateChallenge()
+	if err != nil {
+		return err
+	}
+
+	if err := s.sendChallenge(*webhook, challenge); err != nil {
+		log.WithError(err).WithField("webhook_id", webhook.ID).Warn("Webhook verification failed")
+		return fmt.Errorf("%w: %v", ErrWebhookVerificationFailed, err)
+	}
+
+	verifiedAt := time.Now()
+	updates := map[string]interface{}{"verified": true, "verified_at": verifiedAt}
+	// Webhooks deactivated by a URL change resume deliveries once the new URL is verified
+	if webhook.Reverify {
+		updates["is_active"] = true
+		updates["reverify"] = false
+	}
+	err = s.dbService.GetDB().Model(&models.Webhook{}).Where("id = ? AND url = ?", webhook.ID, webhook.URL).
+		Updates(updates).Error
+	if err != nil {
+		return fmt.Errorf("failed to mark webhook verified: %w", err)
+	}
+	webhook.Verified = true
+	webhook.VerifiedAt = &verifiedAt
+	if webhook.Reverify {
+		webhook.IsActive = true
+		webhook.Reverify = false
+	}
+
+	log.WithField("webhook_id", webhook.ID).Info("Webhook verified")
+	return nil
+}
+
+// sendChallenge posts the challenge to the webhook URL and checks that it was echoed back
+func (s *WebhookService) sendChallenge(webhook models.Webhook, challenge string) error {
+	if err := s.validateURL(webhook.URL); err != nil {
+		return err
+	}
+
+	payloadBytes, err := json.Marshal(map[string]string{
+		"type":      webhookVerificationEvent,
+		"challenge": challenge,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal challenge: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", webhook.URL, bytes.NewBuffer(payloadBytes))
+	if err != nil {
+		return fmt.Errorf("failed to create challenge request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Ignis-Webhooks/1.0")
+	req.Header.Set("X-Webhook-Event", webhookVerificationEvent)
+	req.Header.Set("X-Webhook-Challenge", challenge)
+	if webhook.Secret != "" {
+		req.Header.Set("X-Webhook-Signature", "sha256="+s.generateHMACSignature(payloadBytes, webhook.Secret))
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("endpoint returned %d", resp.StatusCode)
+	}
+	if resp.Header.Get("X-Webhook-Challenge") == challenge {
+		return nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return fmt.Errorf("failed to read challenge response: %w", err)
+	}
+	if strings.TrimSpace(string(body)) == challenge {
+		return nil
+	}
+	var echoed struct {
+		Challenge string `json:"challenge"`
+	}
+	if json.Unmarshal(body, &echoed) == nil && echoed.Challenge == challenge {
+		return nil
+	}
+
+	return fmt.Errorf("endpoint did not echo the challenge")
+}
+
+// generateChallenge returns a random hex token for a verification challenge
+func generateChallenge() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("failed to generate challenge: %w", err)
+	}
+	return hex.EncodeToString(token), nil
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ignis/internal/config"
+	"ignis/internal/models"
+
+	"github.com/nats-io/nats.go"
+	log "github.com/sirupsen/logrus"
+)
+
+// workerVersionPattern limits pinned versions to characters that are safe in a NATS subject
+var workerVersionPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,49}$`)
+
+// workerRegistry tracks which worker versions are running, from the heartbeats workers publish
+// on "workers.heartbeat". A version is available until WORKER_VERSION_TTL passes without a heartbeat.
+type workerRegistry struct {
+	mutex    sync.Mutex
+	ttl      time.Duration
+	lastSeen map[string]time.Time // keyed by version
+}
+
+// listenForWorkerHeartbeats records the versions advertised by workers
+func (s *JobService) listenForWorkerHeartbeats() {
+	s.workers = &workerRegistry{
+		ttl:      config.GetEnvDuration("WORKER_VERSION_TTL", 2*time.Minute),
+		lastSeen: make(map[string]time.Time),
+	}
+
+	_, err := s.natsConn.Subscribe("workers.heartbeat", func(msg *nats.Msg) {
+		var heartbeat models.WorkerHeartbeat
+		if err := json.Unmarshal(msg.Data, &heartbeat); err != nil {
+			log.WithError(err).Debug("Failed to unmarshal worker heartbeat")
+			return
+		}
+		if !workerVersionPattern.MatchString(heartbeat.Version) {
+			return
+		}
+
+		s.workers.mutex.Lock()
+		s.workers.lastSeen[heartbeat.Version] = time.Now()
+		s.workers.mutex.Unlock()
+	})
+	if err != nil {
+		log.WithError(err).Error("Failed to subscribe to worker heartbeats")
+	}
+}
+
+// AvailableWorkerVersions returns the worker versions that have sent a heartbeat recently, sorted
+func (s *JobService) AvailableWorkerVersions() []string {
+	s.workers.mutex.Lock()
+	defer s.workers.mutex.Unlock()
+
+	versions := make([]string, 0, len(s.workers.lastSeen))
+	for version, seen := range s.workers.lastSeen {
+		if time.Since(seen) > s.workers.ttl {
+			delete(s.workers.lastSeen, version)
+			continue
+		}
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// validateWorkerVersion checks that a pinned worker version is running somewhere
+func (s *JobService) validateWorkerVersion(version string) error {
+	if version == "" {
+		return nil
+	}
+	if !workerVersionPattern.MatchString(version) {
+		return invalidInput("invalid worker_version %q", version)
+	}
+
+	available := s.AvailableWorkerVersions()
+	for _, candidate := range available {
+		if candidate == version {
+			return nil
+		}
+	}
+	if len(available) == 0 {
+		return invalidInput("worker version %s is not available; no workers have advertised a version", version)
+	}
+	return invalidInput("worker version %s is not available, available versions: %s", version, strings.Join(available, ", "))
+}
+
+// jobSubject returns the NATS subject a job is published to: its priority's subject, or for jobs
+// pinned to a worker version "jobs.pinned.<version>", which only workers of that version subscribe to
+func jobSubject(job models.Job) string {
+	if job.WorkerVersion != "" {
+		return "jobs.pinned." + job.WorkerVersion
+	}
+	return job.EffectivePriority.Subject()
+}